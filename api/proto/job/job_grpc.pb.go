@@ -28,8 +28,43 @@ type JobClient interface {
 	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*Response, error)
 	// Query details about specified job; this function can run on a job of any status
 	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*Response, error)
-	// Get the full output (stdout and stderr) of any existing job
+	// List every job; this function can run on jobs of any status
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Get the client's quota along with the resources its jobs are using
+	Quota(ctx context.Context, in *QuotaRequest, opts ...grpc.CallOption) (*QuotaResponse, error)
+	// Get the full output (stdout and stderr) of any existing job whose output has not been deleted
 	Output(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (Job_OutputClient, error)
+	// Search the stored output of any existing job whose output has not been deleted, streaming back matching lines
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Job_SearchClient, error)
+	// Delete a job that has ended along with its output; the job can no longer be queried afterwards
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Response, error)
+	// Send a signal to the processes of a running job
+	Signal(ctx context.Context, in *SignalRequest, opts ...grpc.CallOption) (*Response, error)
+	// Stream data to the stdin of a job that was started with stdin_stream; stdin stays open after the stream ends
+	// unless it is closed explicitly
+	WriteStdin(ctx context.Context, opts ...grpc.CallOption) (Job_WriteStdinClient, error)
+	// Attach to the terminal of a running job that was started with tty, streaming its input, output and size changes
+	Attach(ctx context.Context, opts ...grpc.CallOption) (Job_AttachClient, error)
+	// Run an additional command inside the cgroup of a running job, streaming its output; only allowed for the
+	// identities the server permits to exec and for jobs that aren't isolated in namespaces
+	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (Job_ExecClient, error)
+	// Submit a workflow of jobs that are run as a DAG: each job starts once the jobs it depends on have succeeded, jobs
+	// that don't depend on each other run in parallel, and jobs that depend on a job that didn't succeed are skipped
+	SubmitWorkflow(ctx context.Context, in *SubmitWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error)
+	// Query the combined status of a workflow along with the status of each of its steps
+	QueryWorkflow(ctx context.Context, in *QueryWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error)
+	// Cancel a workflow, stopping the jobs of its running steps; its pending steps are never started
+	CancelWorkflow(ctx context.Context, in *CancelWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error)
+	// Delete a workflow that has ended so that it can no longer be queried; the jobs of its steps are kept. Workflows are
+	// also deleted once they have ended and the jobs of all of their steps have been deleted
+	DeleteWorkflow(ctx context.Context, in *DeleteWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error)
+	// Create a schedule that starts a new job every time its cron expression matches the current time. Schedules are only
+	// kept in memory, so they are lost when the server restarts and must be created again; the jobs they started are kept
+	CreateSchedule(ctx context.Context, in *CreateScheduleRequest, opts ...grpc.CallOption) (*ScheduleResponse, error)
+	// List every schedule
+	ListSchedules(ctx context.Context, in *ListSchedulesRequest, opts ...grpc.CallOption) (*ListSchedulesResponse, error)
+	// Delete a schedule so that it no longer starts jobs; jobs it already started keep running
+	DeleteSchedule(ctx context.Context, in *DeleteScheduleRequest, opts ...grpc.CallOption) (*ScheduleResponse, error)
 }
 
 type jobClient struct {
@@ -67,6 +102,24 @@ func (c *jobClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *jobClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) Quota(ctx context.Context, in *QuotaRequest, opts ...grpc.CallOption) (*QuotaResponse, error) {
+	out := new(QuotaResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/Quota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *jobClient) Output(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (Job_OutputClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Job_ServiceDesc.Streams[0], "/job.Job/Output", opts...)
 	if err != nil {
@@ -99,6 +152,216 @@ func (x *jobOutputClient) Recv() (*OutputResponse, error) {
 	return m, nil
 }
 
+func (c *jobClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Job_SearchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Job_ServiceDesc.Streams[1], "/job.Job/Search", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobSearchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Job_SearchClient interface {
+	Recv() (*SearchResponse, error)
+	grpc.ClientStream
+}
+
+type jobSearchClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobSearchClient) Recv() (*SearchResponse, error) {
+	m := new(SearchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *jobClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/job.Job/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) Signal(ctx context.Context, in *SignalRequest, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/job.Job/Signal", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) WriteStdin(ctx context.Context, opts ...grpc.CallOption) (Job_WriteStdinClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Job_ServiceDesc.Streams[2], "/job.Job/WriteStdin", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobWriteStdinClient{stream}
+	return x, nil
+}
+
+type Job_WriteStdinClient interface {
+	Send(*StdinRequest) error
+	CloseAndRecv() (*Response, error)
+	grpc.ClientStream
+}
+
+type jobWriteStdinClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobWriteStdinClient) Send(m *StdinRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *jobWriteStdinClient) CloseAndRecv() (*Response, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *jobClient) Attach(ctx context.Context, opts ...grpc.CallOption) (Job_AttachClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Job_ServiceDesc.Streams[3], "/job.Job/Attach", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobAttachClient{stream}
+	return x, nil
+}
+
+type Job_AttachClient interface {
+	Send(*AttachRequest) error
+	Recv() (*AttachResponse, error)
+	grpc.ClientStream
+}
+
+type jobAttachClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobAttachClient) Send(m *AttachRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *jobAttachClient) Recv() (*AttachResponse, error) {
+	m := new(AttachResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *jobClient) Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (Job_ExecClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Job_ServiceDesc.Streams[4], "/job.Job/Exec", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobExecClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Job_ExecClient interface {
+	Recv() (*ExecResponse, error)
+	grpc.ClientStream
+}
+
+type jobExecClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobExecClient) Recv() (*ExecResponse, error) {
+	m := new(ExecResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *jobClient) SubmitWorkflow(ctx context.Context, in *SubmitWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error) {
+	out := new(WorkflowResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/SubmitWorkflow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) QueryWorkflow(ctx context.Context, in *QueryWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error) {
+	out := new(WorkflowResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/QueryWorkflow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) CancelWorkflow(ctx context.Context, in *CancelWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error) {
+	out := new(WorkflowResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/CancelWorkflow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) DeleteWorkflow(ctx context.Context, in *DeleteWorkflowRequest, opts ...grpc.CallOption) (*WorkflowResponse, error) {
+	out := new(WorkflowResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/DeleteWorkflow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) CreateSchedule(ctx context.Context, in *CreateScheduleRequest, opts ...grpc.CallOption) (*ScheduleResponse, error) {
+	out := new(ScheduleResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/CreateSchedule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) ListSchedules(ctx context.Context, in *ListSchedulesRequest, opts ...grpc.CallOption) (*ListSchedulesResponse, error) {
+	out := new(ListSchedulesResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/ListSchedules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobClient) DeleteSchedule(ctx context.Context, in *DeleteScheduleRequest, opts ...grpc.CallOption) (*ScheduleResponse, error) {
+	out := new(ScheduleResponse)
+	err := c.cc.Invoke(ctx, "/job.Job/DeleteSchedule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // JobServer is the server API for Job service.
 // All implementations must embed UnimplementedJobServer
 // for forward compatibility
@@ -109,8 +372,43 @@ type JobServer interface {
 	Stop(context.Context, *StopRequest) (*Response, error)
 	// Query details about specified job; this function can run on a job of any status
 	Query(context.Context, *QueryRequest) (*Response, error)
-	// Get the full output (stdout and stderr) of any existing job
+	// List every job; this function can run on jobs of any status
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// Get the client's quota along with the resources its jobs are using
+	Quota(context.Context, *QuotaRequest) (*QuotaResponse, error)
+	// Get the full output (stdout and stderr) of any existing job whose output has not been deleted
 	Output(*OutputRequest, Job_OutputServer) error
+	// Search the stored output of any existing job whose output has not been deleted, streaming back matching lines
+	Search(*SearchRequest, Job_SearchServer) error
+	// Delete a job that has ended along with its output; the job can no longer be queried afterwards
+	Delete(context.Context, *DeleteRequest) (*Response, error)
+	// Send a signal to the processes of a running job
+	Signal(context.Context, *SignalRequest) (*Response, error)
+	// Stream data to the stdin of a job that was started with stdin_stream; stdin stays open after the stream ends
+	// unless it is closed explicitly
+	WriteStdin(Job_WriteStdinServer) error
+	// Attach to the terminal of a running job that was started with tty, streaming its input, output and size changes
+	Attach(Job_AttachServer) error
+	// Run an additional command inside the cgroup of a running job, streaming its output; only allowed for the
+	// identities the server permits to exec and for jobs that aren't isolated in namespaces
+	Exec(*ExecRequest, Job_ExecServer) error
+	// Submit a workflow of jobs that are run as a DAG: each job starts once the jobs it depends on have succeeded, jobs
+	// that don't depend on each other run in parallel, and jobs that depend on a job that didn't succeed are skipped
+	SubmitWorkflow(context.Context, *SubmitWorkflowRequest) (*WorkflowResponse, error)
+	// Query the combined status of a workflow along with the status of each of its steps
+	QueryWorkflow(context.Context, *QueryWorkflowRequest) (*WorkflowResponse, error)
+	// Cancel a workflow, stopping the jobs of its running steps; its pending steps are never started
+	CancelWorkflow(context.Context, *CancelWorkflowRequest) (*WorkflowResponse, error)
+	// Delete a workflow that has ended so that it can no longer be queried; the jobs of its steps are kept. Workflows are
+	// also deleted once they have ended and the jobs of all of their steps have been deleted
+	DeleteWorkflow(context.Context, *DeleteWorkflowRequest) (*WorkflowResponse, error)
+	// Create a schedule that starts a new job every time its cron expression matches the current time. Schedules are only
+	// kept in memory, so they are lost when the server restarts and must be created again; the jobs they started are kept
+	CreateSchedule(context.Context, *CreateScheduleRequest) (*ScheduleResponse, error)
+	// List every schedule
+	ListSchedules(context.Context, *ListSchedulesRequest) (*ListSchedulesResponse, error)
+	// Delete a schedule so that it no longer starts jobs; jobs it already started keep running
+	DeleteSchedule(context.Context, *DeleteScheduleRequest) (*ScheduleResponse, error)
 	mustEmbedUnimplementedJobServer()
 }
 
@@ -127,9 +425,54 @@ func (UnimplementedJobServer) Stop(context.Context, *StopRequest) (*Response, er
 func (UnimplementedJobServer) Query(context.Context, *QueryRequest) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
 }
+func (UnimplementedJobServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedJobServer) Quota(context.Context, *QuotaRequest) (*QuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Quota not implemented")
+}
 func (UnimplementedJobServer) Output(*OutputRequest, Job_OutputServer) error {
 	return status.Errorf(codes.Unimplemented, "method Output not implemented")
 }
+func (UnimplementedJobServer) Search(*SearchRequest, Job_SearchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedJobServer) Delete(context.Context, *DeleteRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedJobServer) Signal(context.Context, *SignalRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Signal not implemented")
+}
+func (UnimplementedJobServer) WriteStdin(Job_WriteStdinServer) error {
+	return status.Errorf(codes.Unimplemented, "method WriteStdin not implemented")
+}
+func (UnimplementedJobServer) Attach(Job_AttachServer) error {
+	return status.Errorf(codes.Unimplemented, "method Attach not implemented")
+}
+func (UnimplementedJobServer) Exec(*ExecRequest, Job_ExecServer) error {
+	return status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (UnimplementedJobServer) SubmitWorkflow(context.Context, *SubmitWorkflowRequest) (*WorkflowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitWorkflow not implemented")
+}
+func (UnimplementedJobServer) QueryWorkflow(context.Context, *QueryWorkflowRequest) (*WorkflowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryWorkflow not implemented")
+}
+func (UnimplementedJobServer) CancelWorkflow(context.Context, *CancelWorkflowRequest) (*WorkflowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelWorkflow not implemented")
+}
+func (UnimplementedJobServer) DeleteWorkflow(context.Context, *DeleteWorkflowRequest) (*WorkflowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWorkflow not implemented")
+}
+func (UnimplementedJobServer) CreateSchedule(context.Context, *CreateScheduleRequest) (*ScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSchedule not implemented")
+}
+func (UnimplementedJobServer) ListSchedules(context.Context, *ListSchedulesRequest) (*ListSchedulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSchedules not implemented")
+}
+func (UnimplementedJobServer) DeleteSchedule(context.Context, *DeleteScheduleRequest) (*ScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSchedule not implemented")
+}
 func (UnimplementedJobServer) mustEmbedUnimplementedJobServer() {}
 
 // UnsafeJobServer may be embedded to opt out of forward compatibility for this service.
@@ -197,6 +540,42 @@ func _Job_Query_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Job_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_Quota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).Quota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/Quota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).Quota(ctx, req.(*QuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Job_Output_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(OutputRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -218,6 +597,262 @@ func (x *jobOutputServer) Send(m *OutputResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Job_Search_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobServer).Search(m, &jobSearchServer{stream})
+}
+
+type Job_SearchServer interface {
+	Send(*SearchResponse) error
+	grpc.ServerStream
+}
+
+type jobSearchServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobSearchServer) Send(m *SearchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Job_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_Signal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).Signal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/Signal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).Signal(ctx, req.(*SignalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_WriteStdin_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(JobServer).WriteStdin(&jobWriteStdinServer{stream})
+}
+
+type Job_WriteStdinServer interface {
+	SendAndClose(*Response) error
+	Recv() (*StdinRequest, error)
+	grpc.ServerStream
+}
+
+type jobWriteStdinServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobWriteStdinServer) SendAndClose(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *jobWriteStdinServer) Recv() (*StdinRequest, error) {
+	m := new(StdinRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Job_Attach_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(JobServer).Attach(&jobAttachServer{stream})
+}
+
+type Job_AttachServer interface {
+	Send(*AttachResponse) error
+	Recv() (*AttachRequest, error)
+	grpc.ServerStream
+}
+
+type jobAttachServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobAttachServer) Send(m *AttachResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *jobAttachServer) Recv() (*AttachRequest, error) {
+	m := new(AttachRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Job_Exec_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobServer).Exec(m, &jobExecServer{stream})
+}
+
+type Job_ExecServer interface {
+	Send(*ExecResponse) error
+	grpc.ServerStream
+}
+
+type jobExecServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobExecServer) Send(m *ExecResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Job_SubmitWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitWorkflowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).SubmitWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/SubmitWorkflow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).SubmitWorkflow(ctx, req.(*SubmitWorkflowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_QueryWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryWorkflowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).QueryWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/QueryWorkflow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).QueryWorkflow(ctx, req.(*QueryWorkflowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_CancelWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelWorkflowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).CancelWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/CancelWorkflow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).CancelWorkflow(ctx, req.(*CancelWorkflowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_DeleteWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWorkflowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).DeleteWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/DeleteWorkflow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).DeleteWorkflow(ctx, req.(*DeleteWorkflowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_CreateSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).CreateSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/CreateSchedule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).CreateSchedule(ctx, req.(*CreateScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_ListSchedules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSchedulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).ListSchedules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/ListSchedules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).ListSchedules(ctx, req.(*ListSchedulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Job_DeleteSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServer).DeleteSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/job.Job/DeleteSchedule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServer).DeleteSchedule(ctx, req.(*DeleteScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Job_ServiceDesc is the grpc.ServiceDesc for Job service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -237,6 +872,50 @@ var Job_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Query",
 			Handler:    _Job_Query_Handler,
 		},
+		{
+			MethodName: "List",
+			Handler:    _Job_List_Handler,
+		},
+		{
+			MethodName: "Quota",
+			Handler:    _Job_Quota_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Job_Delete_Handler,
+		},
+		{
+			MethodName: "Signal",
+			Handler:    _Job_Signal_Handler,
+		},
+		{
+			MethodName: "SubmitWorkflow",
+			Handler:    _Job_SubmitWorkflow_Handler,
+		},
+		{
+			MethodName: "QueryWorkflow",
+			Handler:    _Job_QueryWorkflow_Handler,
+		},
+		{
+			MethodName: "CancelWorkflow",
+			Handler:    _Job_CancelWorkflow_Handler,
+		},
+		{
+			MethodName: "DeleteWorkflow",
+			Handler:    _Job_DeleteWorkflow_Handler,
+		},
+		{
+			MethodName: "CreateSchedule",
+			Handler:    _Job_CreateSchedule_Handler,
+		},
+		{
+			MethodName: "ListSchedules",
+			Handler:    _Job_ListSchedules_Handler,
+		},
+		{
+			MethodName: "DeleteSchedule",
+			Handler:    _Job_DeleteSchedule_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -244,6 +923,27 @@ var Job_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Job_Output_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Search",
+			Handler:       _Job_Search_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteStdin",
+			Handler:       _Job_WriteStdin_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Attach",
+			Handler:       _Job_Attach_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Exec",
+			Handler:       _Job_Exec_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api/proto/job/job.proto",
 }
@@ -31,6 +31,14 @@ func main() {
 
 	logging.Setup(logHandler)
 
+	if cfg.Output.Dir == "" {
+		cfg.Output.Dir = jobs.DefaultOutputDir
+	}
+
+	if err := jobs.ValidateOutputDir(cfg.Output.Dir); err != nil {
+		log.Fatal(err)
+	}
+
 	err := os.Setenv("SSL_CERT_DIR", cfg.Certs.CertDir)
 	if err != nil {
 		log.Fatal(err)
@@ -63,11 +71,103 @@ func main() {
 		Location: time.UTC,
 	}
 
-	job.RegisterJobServer(server, &serve.JobServer{
-		WorkerName: cfg.WorkerName,
-		Clock:      appClock,
-		Jobs:       make(map[string]*jobs.Job),
-	})
+	rotation := jobs.RotationPolicy{
+		MaxSegmentBytes: cfg.Output.Rotation.MaxSegmentBytes,
+		MaxSegmentAge:   cfg.Output.Rotation.MaxSegmentAge.Duration,
+		Compression:     jobs.Compression(cfg.Output.Rotation.Compression),
+	}
+
+	if err := rotation.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	retention := jobs.NewRetention(
+		jobs.RetentionPolicy{
+			TTL:           cfg.Output.TTL.Duration,
+			MaxTotalBytes: cfg.Output.MaxTotalBytes,
+		},
+		appClock,
+	)
+
+	if cfg.Output.SweepInterval.Duration <= 0 {
+		cfg.Output.SweepInterval.Duration = time.Minute
+	}
+
+	go retention.Run(cfg.Output.SweepInterval.Duration)
+
+	schedulerLimits := jobs.SchedulerLimits{
+		MaxRunning:       cfg.Scheduler.MaxRunning,
+		MaxCPUPercentage: cfg.Scheduler.MaxCPUPercentage,
+		MaxMemoryBytes:   cfg.Scheduler.MaxMemoryBytes,
+	}
+
+	if err := schedulerLimits.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	quotas := make(map[string]jobs.Quota, len(cfg.Quotas))
+
+	for id, quota := range cfg.Quotas {
+		quotas[id] = jobs.Quota{
+			MaxJobs:             quota.MaxJobs,
+			MaxCPUPercentage:    quota.MaxCPUPercentage,
+			MaxMemoryBytes:      quota.MaxMemoryBytes,
+			MaxJobCPUPercentage: quota.MaxJobCPUPercentage,
+			MaxJobMemoryBytes:   quota.MaxJobMemoryBytes,
+			MaxOutputBytes:      quota.MaxOutputBytes,
+		}
+
+		if err := quotas[id].Validate(); err != nil {
+			log.Fatalf("invalid quota of %s: %s", id, err)
+		}
+	}
+
+	var store jobs.Store
+
+	if !cfg.Store.Disabled {
+		if cfg.Store.Path == "" {
+			cfg.Store.Path = jobs.DefaultStorePath
+		}
+
+		fileStore, err := jobs.NewFileStore(cfg.Store.Path)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		defer fileStore.Close()
+
+		store = fileStore
+	}
+
+	jobServer := &serve.JobServer{
+		WorkerName:         cfg.WorkerName,
+		Clock:              appClock,
+		Timeout:            cfg.Timeout,
+		ResourceLimits:     cfg.ResourceLimits,
+		InheritEnv:         cfg.InheritEnv,
+		ExecIdentities:     cfg.ExecIdentities,
+		MaxPriority:        cfg.MaxPriority,
+		PriorityIdentities: cfg.PriorityIdentities,
+		OutputDir:          cfg.Output.Dir,
+		Retention:          retention,
+		MaxJobOutputBytes:  cfg.Output.MaxJobBytes,
+		OutputRotation:     rotation,
+		Store:              store,
+		Scheduler:          jobs.NewScheduler(schedulerLimits),
+		OrphanPolicy:       jobs.OrphanPolicy(cfg.Store.Orphans),
+		Quotas:             quotas,
+		Jobs:               make(map[string]*jobs.Job),
+		Workflows:          make(map[string]*jobs.Workflow),
+		Schedules:          make(map[string]*jobs.Schedule),
+		ScheduleJobs:       make(map[string]*job.StartRequest),
+	}
+
+	if err := jobServer.Restore(); err != nil {
+		log.Fatal(err)
+	}
+
+	job.RegisterJobServer(server, jobServer)
 
 	if err = server.Serve(listener); err != nil {
 		logging.Log.Error("Failed to serve listener", "err", err)
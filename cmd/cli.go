@@ -18,8 +18,10 @@ import (
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Printf(
-			"A command must be specified; options are: %s, %s, %s, %s\n",
-			commands.Start, commands.Stop, commands.Query, commands.Output,
+			"A command must be specified; options are: %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s\n",
+			commands.Start, commands.Stop, commands.Query, commands.List, commands.Quota, commands.Output,
+			commands.Signal, commands.Attach, commands.Exec, commands.Search, commands.Delete, commands.Workflow,
+			commands.Schedule, commands.Apply,
 		)
 
 		os.Exit(1)
@@ -38,13 +40,45 @@ func main() {
 	case commands.Query:
 		cmd = &commands.QueryCmd{}
 		flagSet = flag.NewFlagSet(commands.Query, flag.ExitOnError)
+	case commands.List:
+		cmd = &commands.ListCmd{}
+		flagSet = flag.NewFlagSet(commands.List, flag.ExitOnError)
+	case commands.Quota:
+		cmd = &commands.QuotaCmd{}
+		flagSet = flag.NewFlagSet(commands.Quota, flag.ExitOnError)
 	case commands.Output:
 		cmd = &commands.OutputCmd{}
 		flagSet = flag.NewFlagSet(commands.Output, flag.ExitOnError)
+	case commands.Signal:
+		cmd = &commands.SignalCmd{}
+		flagSet = flag.NewFlagSet(commands.Signal, flag.ExitOnError)
+	case commands.Attach:
+		cmd = &commands.AttachCmd{}
+		flagSet = flag.NewFlagSet(commands.Attach, flag.ExitOnError)
+	case commands.Exec:
+		cmd = &commands.ExecCmd{}
+		flagSet = flag.NewFlagSet(commands.Exec, flag.ExitOnError)
+	case commands.Search:
+		cmd = &commands.SearchCmd{}
+		flagSet = flag.NewFlagSet(commands.Search, flag.ExitOnError)
+	case commands.Delete:
+		cmd = &commands.DeleteCmd{}
+		flagSet = flag.NewFlagSet(commands.Delete, flag.ExitOnError)
+	case commands.Workflow:
+		cmd = &commands.WorkflowCmd{}
+		flagSet = flag.NewFlagSet(commands.Workflow, flag.ExitOnError)
+	case commands.Schedule:
+		cmd = &commands.ScheduleCmd{}
+		flagSet = flag.NewFlagSet(commands.Schedule, flag.ExitOnError)
+	case commands.Apply:
+		cmd = &commands.ApplyCmd{}
+		flagSet = flag.NewFlagSet(commands.Apply, flag.ExitOnError)
 	default:
 		fmt.Printf(
-			"Invalid command argument; options are: %s, %s, %s, %s\n",
-			commands.Start, commands.Stop, commands.Query, commands.Output,
+			"Invalid command argument; options are: %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s\n",
+			commands.Start, commands.Stop, commands.Query, commands.List, commands.Quota, commands.Output,
+			commands.Signal, commands.Attach, commands.Exec, commands.Search, commands.Delete, commands.Workflow,
+			commands.Schedule, commands.Apply,
 		)
 
 		os.Exit(1)
@@ -4,8 +4,10 @@ go 1.22.3
 
 require (
 	github.com/google/uuid v1.6.0
+	github.com/klauspost/compress v1.17.8
 	golang.org/x/oauth2 v0.20.0
 	golang.org/x/sys v0.20.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237
 	google.golang.org/grpc v1.64.0
 	google.golang.org/protobuf v1.34.1
 	gopkg.in/yaml.v3 v3.0.1
@@ -15,5 +17,4 @@ require (
 	cloud.google.com/go/compute/metadata v0.3.0 // indirect
 	golang.org/x/net v0.22.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 )
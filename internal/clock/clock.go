@@ -7,6 +7,8 @@ import (
 // Clock Interface to make testing easier.
 type Clock interface {
 	Now() time.Time
+	// After Waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
 }
 
 type Application struct {
@@ -16,3 +18,7 @@ type Application struct {
 func (a *Application) Now() time.Time {
 	return time.Now().In(a.Location)
 }
+
+func (a *Application) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,91 @@
+package serve
+
+import (
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"io"
+)
+
+func (s *JobServer) Attach(stream jobproto.Job_AttachServer) error {
+	req, err := stream.Recv()
+
+	if err != nil {
+		return err
+	}
+
+	logging.Log.Debug("Handling attach request", "request", req)
+
+	job, err := s.findJob(req.Id)
+
+	if err != nil {
+		return err
+	}
+
+	attachment, err := job.Attach()
+
+	if err != nil {
+		logging.Log.Error("Failed to attach to job", "err", err)
+
+		return toStatusError(err)
+	}
+
+	defer attachment.Detach()
+
+	if err := handleAttachRequest(attachment, req); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			req, err := stream.Recv()
+
+			if err == io.EOF {
+				// The client is done sending input but can still receive output
+				return
+			}
+
+			if err != nil {
+				attachment.Detach()
+
+				return
+			}
+
+			if err := handleAttachRequest(attachment, req); err != nil {
+				logging.Log.Error("Failed to handle attach request", "err", err)
+			}
+		}
+	}()
+
+	for output := range attachment.Output() {
+		if err := stream.Send(&jobproto.AttachResponse{Output: output}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handleAttachRequest Write input from the request to the job's terminal and resize it if requested.
+func handleAttachRequest(attachment *jobs.Attachment, req *jobproto.AttachRequest) error {
+	if req.Resize != nil {
+		if err := attachment.Resize(getWindowSize(req.Resize)); err != nil {
+			return err
+		}
+	}
+
+	if len(req.Stdin) > 0 {
+		if _, err := attachment.Write(req.Stdin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getWindowSize(size *jobproto.WindowSize) jobs.WindowSize {
+	return jobs.WindowSize{
+		Rows: uint16(size.GetRows()),
+		Cols: uint16(size.GetCols()),
+	}
+}
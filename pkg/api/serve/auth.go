@@ -0,0 +1,41 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"slices"
+)
+
+// identity Returns the identity of the client, i.e. the common name of its certificate.
+func identity(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+
+	if !ok {
+		return "", errors.New("no peer information for request")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+
+	if !ok {
+		return "", errors.New("request was not made over TLS")
+	}
+
+	if len(tlsInfo.State.PeerCertificates) == 0 {
+		return "", errors.New("client did not present a certificate")
+	}
+
+	return tlsInfo.State.PeerCertificates[0].Subject.CommonName, nil
+}
+
+// isAllowed Returns true if the client's identity is one of the allowed identities.
+func isAllowed(ctx context.Context, allowed []string) bool {
+	id, err := identity(ctx)
+
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(allowed, id)
+}
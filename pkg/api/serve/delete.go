@@ -0,0 +1,31 @@
+package serve
+
+import (
+	"context"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+)
+
+func (s *JobServer) Delete(ctx context.Context, req *jobproto.DeleteRequest) (*jobproto.Response, error) {
+	logging.Log.Debug("Handling delete job request", "request", req)
+
+	job, err := s.findJob(req.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := job.DeleteOutput(); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	s.Retention.Untrack(job)
+	s.removeJob(job)
+
+	pb := ProtoBuf{}
+
+	return &jobproto.Response{
+		Info:           pb.toJobInfo(job),
+		ResourceLimits: pb.toResources(job.Limits()),
+	}, nil
+}
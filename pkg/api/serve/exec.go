@@ -0,0 +1,74 @@
+package serve
+
+import (
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+)
+
+func (s *JobServer) Exec(req *jobproto.ExecRequest, stream jobproto.Job_ExecServer) error {
+	logging.Log.Debug("Handling exec request", "request", req)
+
+	if !isAllowed(stream.Context(), s.ExecIdentities) {
+		id, _ := identity(stream.Context())
+		logging.Log.Warn("Denied exec request", "identity", id, "job", req.Id)
+
+		return status.Error(codes.PermissionDenied, "not allowed to execute commands in jobs")
+	}
+
+	job, err := s.findJob(req.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateCommand("command", req.Command, job.Dir()); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+
+	stdout := &execWriter{mu: &mu, stream: stream, stdout: true}
+	stderr := &execWriter{mu: &mu, stream: stream}
+
+	exitCode, err := job.Exec(stream.Context(), stdout, stderr, req.Command.GetName(), req.Command.GetArgs()...)
+
+	if err != nil {
+		logging.Log.Error("Failed to execute command in job", "err", err)
+
+		return toStatusError(err)
+	}
+
+	code := int32(exitCode)
+
+	return stream.Send(&jobproto.ExecResponse{ExitCode: &code})
+}
+
+// execWriter Sends output written by a command to the client as either stdout or stderr; the mutex is shared between
+// writers since messages cannot be sent on a stream concurrently.
+type execWriter struct {
+	mu     *sync.Mutex
+	stream jobproto.Job_ExecServer
+	stdout bool
+}
+
+func (w *execWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	resp := &jobproto.ExecResponse{}
+
+	if w.stdout {
+		resp.Stdout = p
+	} else {
+		resp.Stderr = p
+	}
+
+	if err := w.stream.Send(resp); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,20 @@
+package serve
+
+import (
+	"context"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+)
+
+func (s *JobServer) List(ctx context.Context, req *jobproto.ListRequest) (*jobproto.ListResponse, error) {
+	logging.Log.Debug("Handling list jobs request", "request", req)
+
+	pb := ProtoBuf{}
+	resp := &jobproto.ListResponse{}
+
+	for _, job := range s.listJobs() {
+		resp.Jobs = append(resp.Jobs, pb.toJobInfo(job))
+	}
+
+	return resp, nil
+}
@@ -1,61 +1,104 @@
 package serve
 
 import (
-	"bufio"
-	"errors"
 	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
 	"github.com/kurczynski/teleport-job-worker/internal/logging"
-	"log"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"io"
 )
 
 func (s *JobServer) Output(req *jobproto.OutputRequest, stream jobproto.Job_OutputServer) error {
 	logging.Log.Debug("Handling output request", "request", req)
 
-	job, ok := s.Jobs[req.Id]
+	job, err := s.findJob(req.Id)
 
-	if !ok {
-		err := errors.New("job does not exist")
-		logging.Log.Error("Failed to open job output", "err", err)
+	if err != nil {
+		return err
+	}
+
+	outputRange := getOutputRange(req)
+
+	if err := outputRange.Validate(); err != nil {
+		return invalidArgument("range", err.Error())
+	}
+
+	filters, err := getFieldFilters(req.Filters)
 
+	if err != nil {
 		return err
 	}
 
-	stdout, _, err := job.Output()
+	stdout, stderr, err := job.Output(outputRange, filters...)
 
 	if err != nil {
 		logging.Log.Error("Failed to open job output", "err", err)
 
-		return err
+		return toStatusError(err)
 	}
 
 	defer stdout.Close()
+	defer stderr.Close()
 
-	log.Printf("Let's read some output from: %s\n", stdout.Name())
+	logging.Log.Debug("Reading job output", "id", job.ID())
 
-	reader := bufio.NewReader(stdout)
-	buf := make([]byte, 0, 1024)
-	var resp *jobproto.OutputResponse
+	buf := make([]byte, 32*1024)
 
 	for {
-		if n, err := reader.Read(buf); err != nil {
-			return err
-		} else {
+		n, err := stdout.Read(buf)
+
+		if n > 0 {
 			logging.Log.Debug("Wrote to buffer", "bytes", n)
 
-			if n <= 0 {
-				break
+			resp := &jobproto.OutputResponse{
+				Stdout: buf[:n],
+				Stderr: nil,
 			}
-		}
 
-		resp = &jobproto.OutputResponse{
-			Stdout: buf,
-			Stderr: nil,
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
 		}
 
-		if err := stream.Send(resp); err != nil {
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
 			return err
 		}
 	}
+}
+
+// getOutputRange Get the part of the job's output to read from the request.
+func getOutputRange(req *jobproto.OutputRequest) jobs.OutputRange {
+	outputRange := jobs.OutputRange{
+		TailLines: req.TailLines,
+		Offset:    req.Offset,
+		Limit:     req.Limit,
+	}
+
+	if req.Since != nil {
+		outputRange.Since = req.Since.AsTime()
+	}
+
+	if req.Until != nil {
+		outputRange.Until = req.Until.AsTime()
+	}
+
+	return outputRange
+}
+
+// getFieldFilters Parse the filters of a request in the form field=value.
+func getFieldFilters(filters []string) ([]jobs.FieldFilter, error) {
+	var result []jobs.FieldFilter
+
+	for _, filter := range filters {
+		fieldFilter, err := jobs.ParseFieldFilter(filter)
+
+		if err != nil {
+			return nil, invalidArgument("filters", err.Error())
+		}
+
+		result = append(result, fieldFilter)
+	}
 
-	return nil
+	return result, nil
 }
@@ -9,10 +9,10 @@ import (
 func (s *JobServer) Query(ctx context.Context, req *jobproto.QueryRequest) (*jobproto.Response, error) {
 	logging.Log.Debug("Handling query request", "request", req)
 
-	job, ok := s.Jobs[req.Id]
+	job, err := s.findJob(req.Id)
 
-	if !ok {
-		return nil, nil
+	if err != nil {
+		return nil, err
 	}
 
 	pb := ProtoBuf{}
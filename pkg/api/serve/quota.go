@@ -0,0 +1,94 @@
+package serve
+
+import (
+	"context"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultQuotaIdentity Identity whose quota applies to every client without a quota of its own.
+const DefaultQuotaIdentity = "*"
+
+func (s *JobServer) Quota(ctx context.Context, req *jobproto.QuotaRequest) (*jobproto.QuotaResponse, error) {
+	logging.Log.Debug("Handling quota request", "request", req)
+
+	id, err := identity(ctx)
+
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	pb := ProtoBuf{}
+	resp := &jobproto.QuotaResponse{
+		Identity: id,
+		Usage:    pb.toQuotaUsage(jobs.UsageOf(s.ownedJobs(id))),
+	}
+
+	if quota, ok := s.quotaFor(id); ok {
+		resp.Limits = pb.toQuotaLimits(quota)
+	}
+
+	return resp, nil
+}
+
+// quotaFor Returns the quota of the client with the identity and true if it has one, falling back to the quota of
+// DefaultQuotaIdentity.
+func (s *JobServer) quotaFor(id string) (jobs.Quota, bool) {
+	if quota, ok := s.Quotas[id]; ok {
+		return quota, true
+	}
+
+	quota, ok := s.Quotas[DefaultQuotaIdentity]
+
+	return quota, ok
+}
+
+// ownedJobs Returns every job started by the client with the identity.
+func (s *JobServer) ownedJobs(id string) []*jobs.Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	owned := make([]*jobs.Job, 0)
+
+	for _, job := range s.Jobs {
+		if job.Owner() == id {
+			owned = append(owned, job)
+		}
+	}
+
+	return owned
+}
+
+// applyQuota Ensure the owner of a job with the resource limits can start it without exceeding their quota, limiting
+// the job's output to the owner's remaining output storage. The returned function must be called once the job has been
+// added so that jobs started at the same time cannot exceed the quota together.
+func (s *JobServer) applyQuota(options *jobs.Options, resourceLimits cgroups.Resources) (func(), error) {
+	quota, ok := s.quotaFor(options.Owner)
+
+	if !ok {
+		return func() {}, nil
+	}
+
+	s.quotaMu.Lock()
+
+	usage := jobs.UsageOf(s.ownedJobs(options.Owner))
+
+	if err := quota.Check(usage, resourceLimits); err != nil {
+		s.quotaMu.Unlock()
+		logging.Log.Warn("Denied start request", "identity", options.Owner, "err", err)
+
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	if remaining := quota.RemainingOutputBytes(usage); remaining > 0 {
+		if options.MaxOutputBytes == 0 || remaining < options.MaxOutputBytes {
+			options.MaxOutputBytes = remaining
+		}
+	}
+
+	return s.quotaMu.Unlock, nil
+}
@@ -0,0 +1,43 @@
+package serve
+
+import (
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+)
+
+// Restore Load the jobs persisted in the server's store so that they can be queried and their output read, reattaching
+// to or reaping the processes of jobs that were running and removing cgroups left behind by jobs; does nothing if the
+// server has no store.
+func (s *JobServer) Restore() error {
+	if s.Store == nil {
+		return nil
+	}
+
+	records, err := s.Store.Load()
+
+	if err != nil {
+		return err
+	}
+
+	reconciliation := jobs.Reconciliation{
+		WorkerName: s.WorkerName,
+		Policy:     s.OrphanPolicy,
+		Clock:      s.Clock,
+		Store:      s.Store,
+		Scheduler:  s.Scheduler,
+	}
+
+	restored, err := reconciliation.Restore(records)
+
+	if err != nil {
+		return err
+	}
+
+	for _, job := range restored {
+		s.addJob(job)
+	}
+
+	logging.Log.Info("Restored jobs from store", "count", len(restored))
+
+	return nil
+}
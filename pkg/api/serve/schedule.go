@@ -0,0 +1,117 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+func (s *JobServer) CreateSchedule(
+	ctx context.Context, req *jobproto.CreateScheduleRequest,
+) (*jobproto.ScheduleResponse, error) {
+	redacted := proto.Clone(req).(*jobproto.CreateScheduleRequest)
+	redacted.Job = redactStartRequest(redacted.Job)
+	logging.Log.Debug("Handling create schedule request", "request", redacted)
+
+	cron, err := jobs.ParseCron(req.Cron)
+
+	if err != nil {
+		return nil, invalidArgument("cron", err.Error())
+	}
+
+	location := time.UTC
+
+	if req.Timezone != "" {
+		if location, err = time.LoadLocation(req.Timezone); err != nil {
+			return nil, invalidArgument("timezone", fmt.Sprintf("unknown time zone: %s", req.Timezone))
+		}
+	}
+
+	if cron.Next(s.Clock.Now(), location).IsZero() {
+		return nil, invalidArgument("cron", fmt.Sprintf("cron expression never matches: %s", req.Cron))
+	}
+
+	if req.Job == nil {
+		return nil, invalidArgument("job", "a job is required")
+	}
+
+	owner, _ := identity(ctx)
+
+	if err := s.validateJobRequest(owner, req.Job); err != nil {
+		return nil, err
+	}
+
+	// Each job is checked against the server's limits and the client's quota again once it is started
+	start := func() (*jobs.Job, error) {
+		return s.startJob(owner, req.Job)
+	}
+
+	schedule, err := jobs.NewSchedule(
+		req.Name, owner, cron, location, s.getConcurrencyPolicy(req.ConcurrencyPolicy), s.Clock, start,
+	)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	s.addSchedule(schedule, req.Job)
+	go schedule.Run()
+
+	pb := ProtoBuf{}
+
+	return &jobproto.ScheduleResponse{Info: pb.toScheduleInfo(schedule, req.Job.Command)}, nil
+}
+
+func (s *JobServer) ListSchedules(
+	ctx context.Context, req *jobproto.ListSchedulesRequest,
+) (*jobproto.ListSchedulesResponse, error) {
+	logging.Log.Debug("Handling list schedules request", "request", req)
+
+	pb := ProtoBuf{}
+	resp := &jobproto.ListSchedulesResponse{}
+
+	for _, schedule := range s.listSchedules() {
+		command := s.getScheduleJob(schedule.ID()).GetCommand()
+
+		resp.Schedules = append(resp.Schedules, pb.toScheduleInfo(schedule, command))
+	}
+
+	return resp, nil
+}
+
+func (s *JobServer) DeleteSchedule(
+	ctx context.Context, req *jobproto.DeleteScheduleRequest,
+) (*jobproto.ScheduleResponse, error) {
+	logging.Log.Debug("Handling delete schedule request", "request", req)
+
+	schedule, err := s.findSchedule(req.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	command := s.getScheduleJob(schedule.ID()).GetCommand()
+
+	schedule.Stop()
+	s.removeSchedule(schedule)
+
+	pb := ProtoBuf{}
+
+	return &jobproto.ScheduleResponse{Info: pb.toScheduleInfo(schedule, command)}, nil
+}
+
+// getConcurrencyPolicy Get the schedule's concurrency policy from its protobuf representation.
+func (s *JobServer) getConcurrencyPolicy(policy jobproto.ConcurrencyPolicy) jobs.ConcurrencyPolicy {
+	switch policy {
+	case jobproto.ConcurrencyPolicy_FORBID:
+		return jobs.ForbidConcurrent
+	case jobproto.ConcurrencyPolicy_REPLACE:
+		return jobs.ReplaceConcurrent
+	}
+
+	return jobs.AllowConcurrent
+}
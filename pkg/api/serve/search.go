@@ -0,0 +1,87 @@
+package serve
+
+import (
+	"fmt"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"regexp"
+)
+
+func (s *JobServer) Search(req *jobproto.SearchRequest, stream jobproto.Job_SearchServer) error {
+	logging.Log.Debug("Handling search request", "request", req)
+
+	job, err := s.findJob(req.Id)
+
+	if err != nil {
+		return err
+	}
+
+	pattern, err := regexp.Compile(req.Pattern)
+
+	if err != nil {
+		return invalidArgument("pattern", err.Error())
+	}
+
+	if req.ContextLines < 0 {
+		return invalidArgument("context_lines", fmt.Sprintf("cannot be negative: %d", req.ContextLines))
+	}
+
+	filters, err := getFieldFilters(req.Filters)
+
+	if err != nil {
+		return err
+	}
+
+	options := jobs.SearchOptions{
+		ContextLines: int(req.ContextLines),
+		Streams:      getStreams(req.Streams),
+		Filters:      filters,
+	}
+
+	logging.Log.Debug("Searching job output", "id", job.ID(), "pattern", req.Pattern)
+
+	pb := ProtoBuf{}
+
+	err = job.Search(stream.Context(), pattern, options, func(match jobs.Match) error {
+		return stream.Send(&jobproto.SearchResponse{
+			Stream:     toOutputStream(match.Stream),
+			Offset:     match.Offset,
+			LineNumber: match.LineNumber,
+			Time:       pb.toTimestamp(match.Time),
+			Line:       match.Line,
+			Context:    match.Context,
+		})
+	})
+
+	if err != nil {
+		logging.Log.Error("Failed to search job output", "err", err)
+
+		return toStatusError(err)
+	}
+
+	return nil
+}
+
+// getStreams Get the streams of output to search from the request.
+func getStreams(streams []jobproto.OutputStream) []jobs.Stream {
+	var result []jobs.Stream
+
+	for _, stream := range streams {
+		if stream == jobproto.OutputStream_STDERR {
+			result = append(result, jobs.StderrStream)
+		} else {
+			result = append(result, jobs.StdoutStream)
+		}
+	}
+
+	return result
+}
+
+func toOutputStream(stream jobs.Stream) jobproto.OutputStream {
+	if stream == jobs.StderrStream {
+		return jobproto.OutputStream_STDERR
+	}
+
+	return jobproto.OutputStream_STDOUT
+}
@@ -3,22 +3,224 @@ package serve
 import (
 	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
 	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/config"
 	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
 	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"slices"
+	"sync"
+	"time"
 )
 
 type JobServer struct {
 	WorkerName string
 	Clock      clock.Clock
+	Timeout    config.Timeout
+	// ResourceLimits Resource limits applied to jobs started without them and the largest limits jobs can be started
+	// with.
+	ResourceLimits config.ResourceLimits
+	InheritEnv     []string
+	OutputDir      string
+	Retention      *jobs.Retention
+	// MaxJobOutputBytes Maximum amount of output a job can write; zero means there is no maximum.
+	MaxJobOutputBytes int64
+	// OutputRotation When the output of jobs is rotated into segments and how closed segments are compressed.
+	OutputRotation jobs.RotationPolicy
+	// ExecIdentities Identities of clients that are allowed to execute commands inside of jobs.
+	ExecIdentities []string
+	// MaxPriority Highest priority that clients without one of the priority identities can start jobs with.
+	MaxPriority int32
+	// PriorityIdentities Identities of clients that are allowed to start jobs with any priority and to preempt running
+	// jobs.
+	PriorityIdentities []string
+	// Store Persists the metadata of jobs so that they survive restarts of the server; jobs are only kept in memory if
+	// nil.
+	Store jobs.Store
+	// Scheduler Starts jobs while the server has capacity for them and queues the rest; jobs start immediately if nil.
+	Scheduler *jobs.Scheduler
+	// OrphanPolicy What is done with the processes of jobs that were running when the server last exited.
+	OrphanPolicy jobs.OrphanPolicy
+	// Quotas Limits on the jobs of each client keyed by their identity; the quota of DefaultQuotaIdentity applies to
+	// clients without one. Clients without a quota have no limits.
+	Quotas map[string]jobs.Quota
+	// quotaMu Serializes starting jobs of clients with a quota so that their usage cannot change while it is checked.
+	quotaMu sync.Mutex
 
 	Jobs map[string]*jobs.Job
+	// Workflows Workflows submitted since the server started keyed by their ID; workflows are not persisted. Ended
+	// workflows are removed once they are deleted or the jobs of all of their steps have been deleted.
+	Workflows map[string]*jobs.Workflow
+	// Schedules Schedules created since the server started keyed by their ID; schedules are not persisted.
+	Schedules map[string]*jobs.Schedule
+	// ScheduleJobs Requests to start the jobs of each schedule keyed by the schedule's ID.
+	ScheduleJobs map[string]*jobproto.StartRequest
+	mu           sync.RWMutex
 
 	jobproto.UnimplementedJobServer
 	credentials.TransportCredentials
 }
 
+// getJob Returns the job with the given ID and true if it exists.
+func (s *JobServer) getJob(id string) (*jobs.Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.Jobs[id]
+
+	return job, ok
+}
+
+// listJobs Returns every job ordered by when they were created.
+func (s *JobServer) listJobs() []*jobs.Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*jobs.Job, 0, len(s.Jobs))
+
+	for _, job := range s.Jobs {
+		list = append(list, job)
+	}
+
+	slices.SortFunc(list, func(a *jobs.Job, b *jobs.Job) int {
+		return a.Created().Compare(b.Created())
+	})
+
+	return list
+}
+
+// addJob Add the job so that it can be found by its ID and apply the retention policy to its output.
+func (s *JobServer) addJob(job *jobs.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Jobs[job.ID()] = job
+	s.Retention.Track(job)
+}
+
+// getWorkflow Returns the workflow with the given ID and true if it exists.
+func (s *JobServer) getWorkflow(id string) (*jobs.Workflow, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workflow, ok := s.Workflows[id]
+
+	return workflow, ok
+}
+
+// addWorkflow Add the workflow so that it can be found by its ID.
+func (s *JobServer) addWorkflow(workflow *jobs.Workflow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Workflows[workflow.ID()] = workflow
+}
+
+// removeWorkflow Remove the workflow so that it can no longer be found by its ID.
+func (s *JobServer) removeWorkflow(workflow *jobs.Workflow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Workflows, workflow.ID())
+}
+
+// getSchedule Returns the schedule with the given ID and true if it exists.
+func (s *JobServer) getSchedule(id string) (*jobs.Schedule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedule, ok := s.Schedules[id]
+
+	return schedule, ok
+}
+
+// listSchedules Returns every schedule ordered by when they were created.
+func (s *JobServer) listSchedules() []*jobs.Schedule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*jobs.Schedule, 0, len(s.Schedules))
+
+	for _, schedule := range s.Schedules {
+		list = append(list, schedule)
+	}
+
+	slices.SortFunc(list, func(a *jobs.Schedule, b *jobs.Schedule) int {
+		return a.Created().Compare(b.Created())
+	})
+
+	return list
+}
+
+// getScheduleJob Returns the request to start the jobs of the schedule with the given ID; nil if it doesn't exist.
+func (s *JobServer) getScheduleJob(id string) *jobproto.StartRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ScheduleJobs[id]
+}
+
+// addSchedule Add the schedule along with the request to start its jobs so that it can be found by its ID.
+func (s *JobServer) addSchedule(schedule *jobs.Schedule, req *jobproto.StartRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Schedules[schedule.ID()] = schedule
+	s.ScheduleJobs[schedule.ID()] = req
+}
+
+// removeSchedule Remove the schedule so that it can no longer be found by its ID.
+func (s *JobServer) removeSchedule(schedule *jobs.Schedule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Schedules, schedule.ID())
+	delete(s.ScheduleJobs, schedule.ID())
+}
+
+// removeJob Remove the job so that it can no longer be found by its ID.
+func (s *JobServer) removeJob(job *jobs.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Jobs, job.ID())
+	s.evictWorkflows(job)
+
+	if s.Store == nil {
+		return
+	}
+
+	if err := s.Store.Delete(job.ID()); err != nil {
+		logging.Log.Error("Failed to delete job from store", "id", job.ID(), "err", err)
+	}
+}
+
+// evictWorkflows Remove the ended workflows that the deleted job was a step of once the jobs of all of their steps have
+// been deleted; the server must be locked.
+func (s *JobServer) evictWorkflows(job *jobs.Job) {
+	for id, workflow := range s.Workflows {
+		if _, ended := workflow.Ended(); !ended {
+			continue
+		}
+
+		steps := workflow.Steps()
+
+		if !slices.ContainsFunc(steps, func(step jobs.StepInfo) bool { return step.JobID == job.ID() }) {
+			continue
+		}
+
+		if slices.ContainsFunc(steps, func(step jobs.StepInfo) bool { return s.Jobs[step.JobID] != nil }) {
+			continue
+		}
+
+		logging.Log.Info("Removing workflow whose jobs were all deleted", "id", id)
+		delete(s.Workflows, id)
+	}
+}
+
 // ProtoBuf Contains functions that convert types to protobufs.
 type ProtoBuf struct{}
 
@@ -26,21 +228,242 @@ func (p *ProtoBuf) toJobInfo(job *jobs.Job) *jobproto.Info {
 	command, args := job.Command()
 
 	return &jobproto.Info{
-		ID:           job.ID(),
-		Status:       p.toStatus(job.Status()),
-		Created:      timestamppb.New(job.Created()),
-		StatusChange: p.toStatusChanges(job.StatusChanges()),
-		Command:      &jobproto.Command{Name: command, Args: args},
+		ID:            job.ID(),
+		Status:        p.toStatus(job.Status()),
+		Created:       timestamppb.New(job.Created()),
+		StatusChange:  p.toStatusChanges(job.StatusChanges()),
+		Command:       &jobproto.Command{Name: command, Args: args},
+		StatusInfo:    job.StatusInfo(),
+		SignalEvents:  p.toSignalEvents(job.SignalEvents()),
+		Timeout:       durationpb.New(job.Timeout()),
+		Deadline:      p.toTimestamp(job.Deadline()),
+		RestartPolicy: p.toRestartPolicy(job.RestartPolicy()),
+		Runs:          p.toRuns(job.Runs()),
+		WorkingDir:    job.Dir(),
+		Umask:         p.toUmask(job.Umask()),
+		Tty:           job.TTY(),
+		OutputDeleted: job.OutputDeleted(),
+		OutputFormat:  p.toOutputFormat(job.OutputFormat()),
+		QueuePosition: int32(job.QueuePosition()),
+		Priority:      int32(job.Priority()),
+		Owner:         job.Owner(),
+		Labels:        job.Labels(),
+		Isolation:     p.toIsolation(job.Isolation()),
+	}
+}
+
+func (p *ProtoBuf) toWorkflowInfo(workflow *jobs.Workflow) *jobproto.WorkflowInfo {
+	ended, _ := workflow.Ended()
+
+	info := &jobproto.WorkflowInfo{
+		ID:      workflow.ID(),
+		Name:    workflow.Name(),
+		Status:  p.toWorkflowStatus(workflow.Status()),
+		Created: p.toTimestamp(workflow.Created()),
+		Ended:   p.toTimestamp(ended),
+		Owner:   workflow.Owner(),
+	}
+
+	for _, step := range workflow.Steps() {
+		info.Steps = append(info.Steps, &jobproto.WorkflowStepInfo{
+			Name:       step.Name,
+			DependsOn:  step.DependsOn,
+			Status:     p.toStepStatus(step.Status),
+			JobId:      step.JobID,
+			StatusInfo: step.Info,
+		})
+	}
+
+	return info
+}
+
+func (p *ProtoBuf) toWorkflowStatus(status jobs.WorkflowStatus) jobproto.WorkflowStatus {
+	switch status {
+	case jobs.SucceededWorkflow:
+		return jobproto.WorkflowStatus_WORKFLOW_SUCCEEDED
+	case jobs.FailedWorkflow:
+		return jobproto.WorkflowStatus_WORKFLOW_FAILED
+	case jobs.CanceledWorkflow:
+		return jobproto.WorkflowStatus_WORKFLOW_CANCELED
+	}
+
+	return jobproto.WorkflowStatus_WORKFLOW_RUNNING
+}
+
+func (p *ProtoBuf) toStepStatus(status jobs.StepStatus) jobproto.StepStatus {
+	switch status {
+	case jobs.PendingStep:
+		return jobproto.StepStatus_STEP_PENDING
+	case jobs.RunningStep:
+		return jobproto.StepStatus_STEP_RUNNING
+	case jobs.SucceededStep:
+		return jobproto.StepStatus_STEP_SUCCEEDED
+	case jobs.FailedStep:
+		return jobproto.StepStatus_STEP_FAILED
+	case jobs.SkippedStep:
+		return jobproto.StepStatus_STEP_SKIPPED
+	case jobs.CanceledStep:
+		return jobproto.StepStatus_STEP_CANCELED
+	}
+
+	logging.Log.Warn("Unknown workflow step status", "status", status)
+
+	return jobproto.StepStatus_STEP_UNKNOWN
+}
+
+func (p *ProtoBuf) toScheduleInfo(schedule *jobs.Schedule, command *jobproto.Command) *jobproto.ScheduleInfo {
+	info := &jobproto.ScheduleInfo{
+		ID:                schedule.ID(),
+		Name:              schedule.Name(),
+		Cron:              schedule.Cron().String(),
+		Timezone:          schedule.Location().String(),
+		ConcurrencyPolicy: p.toConcurrencyPolicy(schedule.Policy()),
+		Command:           command,
+		Created:           p.toTimestamp(schedule.Created()),
+		LastRun:           p.toTimestamp(schedule.LastRun()),
+		NextRun:           p.toTimestamp(schedule.NextRun()),
+		JobIds:            schedule.JobIDs(),
+		SkippedRuns:       int32(schedule.Skipped()),
+		Owner:             schedule.Owner(),
+	}
+
+	if err := schedule.LastError(); err != nil {
+		info.LastError = err.Error()
+	}
+
+	return info
+}
+
+func (p *ProtoBuf) toConcurrencyPolicy(policy jobs.ConcurrencyPolicy) jobproto.ConcurrencyPolicy {
+	switch policy {
+	case jobs.ForbidConcurrent:
+		return jobproto.ConcurrencyPolicy_FORBID
+	case jobs.ReplaceConcurrent:
+		return jobproto.ConcurrencyPolicy_REPLACE
+	}
+
+	return jobproto.ConcurrencyPolicy_ALLOW
+}
+
+func (p *ProtoBuf) toQuotaLimits(quota jobs.Quota) *jobproto.QuotaLimits {
+	return &jobproto.QuotaLimits{
+		MaxJobs:             int32(quota.MaxJobs),
+		MaxCpuPercentage:    quota.MaxCPUPercentage,
+		MaxMemoryBytes:      quota.MaxMemoryBytes,
+		MaxJobCpuPercentage: quota.MaxJobCPUPercentage,
+		MaxJobMemoryBytes:   quota.MaxJobMemoryBytes,
+		MaxOutputBytes:      quota.MaxOutputBytes,
+	}
+}
+
+func (p *ProtoBuf) toQuotaUsage(usage jobs.Usage) *jobproto.QuotaUsage {
+	return &jobproto.QuotaUsage{
+		Jobs:          int32(usage.Jobs),
+		CpuPercentage: usage.CPUPercentage,
+		MemoryBytes:   usage.MemoryBytes,
+		OutputBytes:   usage.OutputBytes,
+	}
+}
+
+func (p *ProtoBuf) toOutputFormat(format jobs.OutputFormat) jobproto.OutputFormat {
+	if format == jobs.JSONFormat {
+		return jobproto.OutputFormat_JSON
+	}
+
+	return jobproto.OutputFormat_TEXT
+}
+
+func (p *ProtoBuf) toIsolation(isolation jobs.Isolation) *jobproto.Isolation {
+	if isolation == (jobs.Isolation{}) {
+		return nil
+	}
+
+	return &jobproto.Isolation{Pid: isolation.PID, Mount: isolation.Mount, Network: isolation.Network}
+}
+
+func (p *ProtoBuf) toUmask(umask *int) *uint32 {
+	if umask == nil {
+		return nil
+	}
+
+	pbUmask := uint32(*umask)
+
+	return &pbUmask
+}
+
+func (p *ProtoBuf) toRestartPolicy(policy jobs.RestartPolicy) *jobproto.RestartPolicy {
+	pbPolicy := &jobproto.RestartPolicy{
+		Mode:       jobproto.RestartMode_NEVER,
+		MaxRetries: int32(policy.MaxRetries),
+	}
+
+	switch policy.Mode {
+	case jobs.RestartOnFailure:
+		pbPolicy.Mode = jobproto.RestartMode_ON_FAILURE
+	case jobs.AlwaysRestart:
+		pbPolicy.Mode = jobproto.RestartMode_ALWAYS
+	}
+
+	if policy.InitialBackoff != 0 {
+		pbPolicy.InitialBackoff = durationpb.New(policy.InitialBackoff)
+	}
+
+	if policy.MaxBackoff != 0 {
+		pbPolicy.MaxBackoff = durationpb.New(policy.MaxBackoff)
+	}
+
+	return pbPolicy
+}
+
+func (p *ProtoBuf) toRuns(runs []jobs.Run) []*jobproto.Run {
+	pbRuns := make([]*jobproto.Run, 0)
+
+	for _, run := range runs {
+		pbRun := &jobproto.Run{
+			Attempt:   int32(run.Attempt),
+			StartedAt: timestamppb.New(run.StartedAt),
+			EndedAt:   p.toTimestamp(run.EndedAt),
+			ExitCode:  int32(run.ExitCode),
+			Stdout:    &jobproto.OutputSegment{Offset: run.Stdout.Offset, Length: run.Stdout.Length},
+			Stderr:    &jobproto.OutputSegment{Offset: run.Stderr.Offset, Length: run.Stderr.Length},
+		}
+
+		pbRuns = append(pbRuns, pbRun)
+	}
+
+	return pbRuns
+}
+
+// toTimestamp Converts the time to a protobuf timestamp; zero times are left unset.
+func (p *ProtoBuf) toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
 	}
+
+	return timestamppb.New(t)
 }
 
 func (p *ProtoBuf) toStatus(status jobs.Status) jobproto.Status {
-	switch string(status) {
-	case jobproto.Status_READY.String():
+	switch status {
+	case jobs.RunningStatus:
+		return jobproto.Status_RUNNING
+	case jobs.ReadyStatus:
 		return jobproto.Status_READY
+	case jobs.StoppedStatus:
+		return jobproto.Status_STOPPED
+	case jobs.FailedStatus:
+		return jobproto.Status_FAILED
+	case jobs.SucceededStatus:
+		return jobproto.Status_SUCCESS
+	case jobs.RestartingStatus:
+		return jobproto.Status_RESTARTING
+	case jobs.QueuedStatus:
+		return jobproto.Status_QUEUED
 	}
 
-	return jobproto.Status_RUNNING
+	logging.Log.Warn("Unknown job status", "status", status)
+
+	return jobproto.Status_UNKNOWN
 }
 
 func (p *ProtoBuf) toStatusChanges(statusChanges []jobs.StatusChange) []*jobproto.StatusChange {
@@ -52,6 +475,7 @@ func (p *ProtoBuf) toStatusChanges(statusChanges []jobs.StatusChange) []*jobprot
 		pbStatusChange := &jobproto.StatusChange{
 			Status:    pbStatus,
 			ChangedAt: timestamppb.New(statusChange.ChangedAt),
+			Info:      statusChange.Info,
 		}
 
 		pbStatusChanges = append(pbStatusChanges, pbStatusChange)
@@ -60,10 +484,49 @@ func (p *ProtoBuf) toStatusChanges(statusChanges []jobs.StatusChange) []*jobprot
 	return pbStatusChanges
 }
 
+func (p *ProtoBuf) toSignalEvents(signalEvents []jobs.SignalEvent) []*jobproto.SignalEvent {
+	pbSignalEvents := make([]*jobproto.SignalEvent, 0)
+
+	for _, signalEvent := range signalEvents {
+		pbSignalEvent := &jobproto.SignalEvent{
+			Signal: unix.SignalName(signalEvent.Signal),
+			Target: p.toSignalTarget(signalEvent.Target),
+			SentAt: timestamppb.New(signalEvent.SentAt),
+		}
+
+		pbSignalEvents = append(pbSignalEvents, pbSignalEvent)
+	}
+
+	return pbSignalEvents
+}
+
+func (p *ProtoBuf) toSignalTarget(target jobs.SignalTarget) jobproto.SignalTarget {
+	switch target {
+	case jobs.GroupTarget:
+		return jobproto.SignalTarget_GROUP
+	case jobs.CgroupTarget:
+		return jobproto.SignalTarget_CGROUP
+	}
+
+	return jobproto.SignalTarget_LEADER
+}
+
+// toResources Convert resource limits to a protobuf; limits of zero are unlimited, so they are left unset.
 func (p *ProtoBuf) toResources(resources cgroups.Resources) *jobproto.Resources {
 	return &jobproto.Resources{
-		MemoryBytes:   resources.MemoryBytes,
-		CpuPercentage: resources.CPUPercentage,
-		DiskIoBps:     resources.DiskIOBPS,
+		MemoryBytes:   toOptional(resources.MemoryBytes),
+		CpuPercentage: toOptional(resources.CPUPercentage),
+		DiskIoBps:     toOptional(resources.DiskIOBPS),
 	}
 }
+
+// toOptional Returns a pointer to the value; nil if it is zero.
+func toOptional[T comparable](value T) *T {
+	var zero T
+
+	if value == zero {
+		return nil
+	}
+
+	return &value
+}
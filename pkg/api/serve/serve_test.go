@@ -0,0 +1,16 @@
+package serve
+
+import (
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Handlers log every request, which the server's main sets up the logger for
+	logging.Setup(slog.NewTextHandler(io.Discard, nil))
+
+	os.Exit(m.Run())
+}
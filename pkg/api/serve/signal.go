@@ -0,0 +1,46 @@
+package serve
+
+import (
+	"context"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+)
+
+func (s *JobServer) Signal(ctx context.Context, req *jobproto.SignalRequest) (*jobproto.Response, error) {
+	logging.Log.Debug("Handling signal job request", "request", req)
+
+	job, err := s.findJob(req.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := jobs.ParseSignal(req.Signal)
+
+	if err != nil {
+		return nil, invalidArgument("signal", err.Error())
+	}
+
+	if err := job.Signal(sig, getSignalTarget(req)); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	pb := ProtoBuf{}
+
+	return &jobproto.Response{
+		Info:           pb.toJobInfo(job),
+		ResourceLimits: pb.toResources(job.Limits()),
+	}, nil
+}
+
+func getSignalTarget(req *jobproto.SignalRequest) jobs.SignalTarget {
+	switch req.Target {
+	case jobproto.SignalTarget_GROUP:
+		return jobs.GroupTarget
+	case jobproto.SignalTarget_CGROUP:
+		return jobs.CgroupTarget
+	}
+
+	return jobs.LeaderTarget
+}
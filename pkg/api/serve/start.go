@@ -2,30 +2,32 @@ package serve
 
 import (
 	"context"
+	"fmt"
 	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
 	"github.com/kurczynski/teleport-job-worker/internal/logging"
 	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
 	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"slices"
 )
 
-func (s *JobServer) Start(ctx context.Context, req *jobproto.StartRequest) (*jobproto.Response, error) {
-	logging.Log.Debug("Handling start job request", "request", req)
+// redactedValue Logged in place of values that may contain secrets.
+const redactedValue = "REDACTED"
 
-	// TODO: The request should be validated before creating a job
-	job, err := jobs.NewJob(s.WorkerName, s.Clock, getResourceLimits(req), req.Command.Name, req.Command.Args...)
+func (s *JobServer) Start(ctx context.Context, req *jobproto.StartRequest) (*jobproto.Response, error) {
+	logging.Log.Debug("Handling start job request", "request", redactStartRequest(req))
 
-	if err != nil {
-		return nil, err
-	}
+	// Clients without an identity can only be limited by the default quota
+	owner, _ := identity(ctx)
 
-	err = job.Start()
+	job, err := s.startJob(owner, req)
 
 	if err != nil {
 		return nil, err
 	}
 
-	s.Jobs[job.ID()] = job
-
 	pb := ProtoBuf{}
 
 	return &jobproto.Response{
@@ -34,10 +36,300 @@ func (s *JobServer) Start(ctx context.Context, req *jobproto.StartRequest) (*job
 	}, nil
 }
 
-func getResourceLimits(req *jobproto.StartRequest) cgroups.Resources {
+// redactStartRequest Returns a copy of the request that can be logged, without the values of its environment variables
+// and its stdin data since they may contain secrets.
+func redactStartRequest(req *jobproto.StartRequest) *jobproto.StartRequest {
+	if req == nil {
+		return nil
+	}
+
+	redacted := proto.Clone(req).(*jobproto.StartRequest)
+
+	for key := range redacted.Env {
+		redacted.Env[key] = redactedValue
+	}
+
+	if len(redacted.Stdin) > 0 {
+		redacted.Stdin = []byte(redactedValue)
+	}
+
+	return redacted
+}
+
+// startJob Create a job owned by the client from the request and start it, or submit it to the server's scheduler; the
+// error is a gRPC error.
+func (s *JobServer) startJob(owner string, req *jobproto.StartRequest) (*jobs.Job, error) {
+	if err := validateStartRequest(req); err != nil {
+		return nil, err
+	}
+
+	options, err := s.getOptions(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validatePriority(owner, req); err != nil {
+		return nil, err
+	}
+
+	resourceLimits, err := s.getResourceLimits(req.ResourceLimits)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options.Owner = owner
+
+	release, err := s.applyQuota(&options, resourceLimits)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	if s.Scheduler != nil {
+		if err := s.Scheduler.Admit(resourceLimits); err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+	}
+
+	job, err := jobs.NewJob(s.WorkerName, s.Clock, resourceLimits, options, req.Command.Name, req.Command.Args...)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if s.Scheduler != nil {
+		err = s.Scheduler.Submit(job)
+	} else {
+		err = job.Start()
+	}
+
+	// The job was never added, so nothing else would remove what was created for it
+	if err != nil {
+		job.Discard()
+
+		return nil, toStatusError(err)
+	}
+
+	s.addJob(job)
+
+	return job, nil
+}
+
+// validateJobRequest Ensure a request of the client to start a job later, e.g. the job of a workflow step, is valid and
+// within the server's limits; the client's quota is only checked once the job is started.
+func (s *JobServer) validateJobRequest(owner string, req *jobproto.StartRequest) error {
+	if err := validateStartRequest(req); err != nil {
+		return err
+	}
+
+	if _, err := s.getOptions(req); err != nil {
+		return err
+	}
+
+	if err := s.validatePriority(owner, req); err != nil {
+		return err
+	}
+
+	_, err := s.getResourceLimits(req.ResourceLimits)
+
+	return err
+}
+
+// validatePriority Ensure the client is allowed to start the job with its priority and preemption; clients without one
+// of the priority identities cannot preempt running jobs or use priorities above the server's maximum.
+func (s *JobServer) validatePriority(owner string, req *jobproto.StartRequest) error {
+	if slices.Contains(s.PriorityIdentities, owner) {
+		return nil
+	}
+
+	if req.Preempt {
+		return invalidArgument("preempt", "not allowed to preempt running jobs")
+	}
+
+	if req.Priority > s.MaxPriority {
+		return invalidArgument("priority", fmt.Sprintf("%d exceeds maximum of %d", req.Priority, s.MaxPriority))
+	}
+
+	return nil
+}
+
+// getResourceLimits Get the job's resource limits from the request, applying the server's default for each limit that
+// is unset and ensuring no limit exceeds the server's maximum. A limit of zero is unlimited, so it can only come from
+// an unset limit without a default.
+func (s *JobServer) getResourceLimits(req *jobproto.Resources) (cgroups.Resources, error) {
+	defaults, maximums := s.ResourceLimits.Default, s.ResourceLimits.Max
+
+	if req == nil {
+		req = &jobproto.Resources{}
+	}
+
+	memory, err := getResourceLimit(
+		"resource_limits.memory_bytes", req.MemoryBytes, defaults.MemoryBytes, maximums.MemoryBytes,
+	)
+
+	if err != nil {
+		return cgroups.Resources{}, err
+	}
+
+	cpu, err := getResourceLimit(
+		"resource_limits.cpu_percentage", req.CpuPercentage, defaults.CPUPercentage, maximums.CPUPercentage,
+	)
+
+	if err != nil {
+		return cgroups.Resources{}, err
+	}
+
+	diskIO, err := getResourceLimit("resource_limits.disk_io_bps", req.DiskIoBps, defaults.DiskIOBPS, maximums.DiskIOBPS)
+
+	if err != nil {
+		return cgroups.Resources{}, err
+	}
+
 	return cgroups.Resources{
-		CPUPercentage: req.ResourceLimits.CpuPercentage,
-		DiskIOBPS:     req.ResourceLimits.DiskIoBps,
-		MemoryBytes:   req.ResourceLimits.MemoryBytes,
+		CPUPercentage: cpu,
+		DiskIOBPS:     diskIO,
+		MemoryBytes:   memory,
+	}, nil
+}
+
+// getResourceLimit Get a single resource limit from the request. An unset limit is the default capped at the maximum,
+// or the maximum if there is no default; zero means there is neither, i.e. the resource is unlimited.
+func getResourceLimit[T int32 | uint64](field string, value *T, defaultValue T, maxValue T) (T, error) {
+	if value == nil {
+		if defaultValue > 0 && (maxValue <= 0 || defaultValue <= maxValue) {
+			return defaultValue, nil
+		}
+
+		return maxValue, nil
+	}
+
+	if *value <= 0 {
+		return 0, invalidArgument(field, fmt.Sprintf("must be positive: %d", *value))
+	}
+
+	if maxValue > 0 && *value > maxValue {
+		return 0, invalidArgument(field, fmt.Sprintf("%d exceeds maximum of %d", *value, maxValue))
+	}
+
+	return *value, nil
+}
+
+// getEnv Get the job's environment variables from the request in the form "KEY=value"; sorted so jobs are reproducible.
+func getEnv(req *jobproto.StartRequest) []string {
+	env := make([]string, 0, len(req.Env))
+
+	for key, value := range req.Env {
+		env = append(env, key+"="+value)
+	}
+
+	slices.Sort(env)
+
+	return env
+}
+
+func getRestartPolicy(req *jobproto.StartRequest) jobs.RestartPolicy {
+	policy := jobs.RestartPolicy{
+		Mode:           jobs.NeverRestart,
+		MaxRetries:     int(req.RestartPolicy.GetMaxRetries()),
+		InitialBackoff: req.RestartPolicy.GetInitialBackoff().AsDuration(),
+		MaxBackoff:     req.RestartPolicy.GetMaxBackoff().AsDuration(),
+	}
+
+	switch req.RestartPolicy.GetMode() {
+	case jobproto.RestartMode_ON_FAILURE:
+		policy.Mode = jobs.RestartOnFailure
+	case jobproto.RestartMode_ALWAYS:
+		policy.Mode = jobs.AlwaysRestart
+	}
+
+	return policy
+}
+
+func getIsolation(isolation *jobproto.Isolation) jobs.Isolation {
+	return jobs.Isolation{
+		PID:     isolation.GetPid(),
+		Mount:   isolation.GetMount(),
+		Network: isolation.GetNetwork(),
+	}
+}
+
+func getOutputFormat(format jobproto.OutputFormat) jobs.OutputFormat {
+	if format == jobproto.OutputFormat_JSON {
+		return jobs.JSONFormat
+	}
+
+	return jobs.TextFormat
+}
+
+// getOptions Get the job's options from the request, applying the server's default timeout and ensuring the job cannot
+// run longer than the server's maximum timeout.
+func (s *JobServer) getOptions(req *jobproto.StartRequest) (jobs.Options, error) {
+	options := jobs.Options{
+		Timeout:        s.Timeout.Default.Duration,
+		RestartPolicy:  getRestartPolicy(req),
+		Env:            getEnv(req),
+		InheritEnv:     s.InheritEnv,
+		Dir:            req.WorkingDir,
+		StdinStream:    req.StdinStream,
+		TTY:            req.Tty,
+		WindowSize:     getWindowSize(req.WindowSize),
+		OutputDir:      s.OutputDir,
+		MaxOutputBytes: s.MaxJobOutputBytes,
+		RotationPolicy: s.OutputRotation,
+		OutputFormat:   getOutputFormat(req.OutputFormat),
+		Store:          s.Store,
+		Priority:       int(req.Priority),
+		Preempt:        req.Preempt,
+		Labels:         req.Labels,
+		Isolation:      getIsolation(req.Isolation),
+	}
+
+	if len(req.Stdin) > 0 {
+		options.Stdin = req.Stdin
+	}
+
+	if req.Umask != nil {
+		umask := int(*req.Umask)
+		options.Umask = &umask
+	}
+
+	if req.Timeout != nil {
+		options.Timeout = req.Timeout.AsDuration()
+	}
+
+	if req.Deadline != nil {
+		options.Deadline = req.Deadline.AsTime()
 	}
+
+	maxTimeout := s.Timeout.Max.Duration
+
+	if options.Timeout < 0 {
+		return options, invalidArgument("timeout", fmt.Sprintf("cannot be negative: %s", options.Timeout))
+	}
+
+	if maxTimeout <= 0 {
+		return options, nil
+	}
+
+	if options.Timeout == 0 && options.Deadline.IsZero() {
+		return options, invalidArgument(
+			"timeout", fmt.Sprintf("a timeout or deadline is required; maximum is %s", maxTimeout),
+		)
+	}
+
+	if options.Timeout > maxTimeout {
+		return options, invalidArgument("timeout", fmt.Sprintf("%s exceeds maximum of %s", options.Timeout, maxTimeout))
+	}
+
+	if latest := s.Clock.Now().Add(maxTimeout); options.Deadline.After(latest) {
+		return options, invalidArgument(
+			"deadline", fmt.Sprintf("%s is later than the maximum of %s", options.Deadline, latest),
+		)
+	}
+
+	return options, nil
 }
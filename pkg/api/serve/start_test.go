@@ -0,0 +1,240 @@
+package serve
+
+import (
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"github.com/kurczynski/teleport-job-worker/pkg/config"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestJobServer_getResourceLimits(t *testing.T) {
+	memory, cpu, diskIO := uint64(2048), int32(150), int32(-1)
+
+	tests := []struct {
+		name     string
+		limits   config.ResourceLimits
+		req      *jobproto.Resources
+		want     cgroups.Resources
+		wantCode codes.Code
+	}{
+		{
+			name:     "Should leave resources unlimited without defaults or maximums",
+			want:     cgroups.Resources{},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Should apply defaults to unset limits",
+			limits: config.ResourceLimits{
+				Default: config.Resources{MemoryBytes: 1024, CPUPercentage: 100, DiskIOBPS: 4096},
+			},
+			want:     cgroups.Resources{MemoryBytes: 1024, CPUPercentage: 100, DiskIOBPS: 4096},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Should apply maximums to unset limits without defaults",
+			limits: config.ResourceLimits{
+				Max: config.Resources{MemoryBytes: 4096, CPUPercentage: 200},
+			},
+			want:     cgroups.Resources{MemoryBytes: 4096, CPUPercentage: 200},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Should cap defaults at the maximum",
+			limits: config.ResourceLimits{
+				Default: config.Resources{MemoryBytes: 8192},
+				Max:     config.Resources{MemoryBytes: 4096},
+			},
+			want:     cgroups.Resources{MemoryBytes: 4096},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Should keep limits within the maximum",
+			limits: config.ResourceLimits{
+				Default: config.Resources{MemoryBytes: 1024, CPUPercentage: 100},
+				Max:     config.Resources{MemoryBytes: 4096, CPUPercentage: 200},
+			},
+			req:      &jobproto.Resources{MemoryBytes: &memory, CpuPercentage: &cpu},
+			want:     cgroups.Resources{MemoryBytes: 2048, CPUPercentage: 150},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Should reject limits above the maximum",
+			limits: config.ResourceLimits{
+				Max: config.Resources{CPUPercentage: 100},
+			},
+			req:      &jobproto.Resources{CpuPercentage: &cpu},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should reject limits that aren't positive",
+			req:      &jobproto.Resources{DiskIoBps: &diskIO},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &JobServer{ResourceLimits: tt.limits}
+			got, err := server.getResourceLimits(tt.req)
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("getResourceLimits() code = %s, want %s: %v", code, tt.wantCode, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("getResourceLimits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobServer_getOptions(t *testing.T) {
+	now := time.Now()
+	limited := config.Timeout{Default: config.Duration{Duration: time.Hour}, Max: config.Duration{Duration: 2 * time.Hour}}
+
+	tests := []struct {
+		name        string
+		timeout     config.Timeout
+		req         *jobproto.StartRequest
+		wantTimeout time.Duration
+		wantCode    codes.Code
+	}{
+		{
+			name:        "Should apply the default timeout",
+			timeout:     limited,
+			req:         &jobproto.StartRequest{},
+			wantTimeout: time.Hour,
+			wantCode:    codes.OK,
+		},
+		{
+			name:        "Should keep timeouts within the maximum",
+			timeout:     limited,
+			req:         &jobproto.StartRequest{Timeout: durationpb.New(90 * time.Minute)},
+			wantTimeout: 90 * time.Minute,
+			wantCode:    codes.OK,
+		},
+		{
+			name:     "Should reject timeouts above the maximum",
+			timeout:  limited,
+			req:      &jobproto.StartRequest{Timeout: durationpb.New(3 * time.Hour)},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should reject deadlines later than the maximum",
+			timeout:  limited,
+			req:      &jobproto.StartRequest{Deadline: timestamppb.New(now.Add(3 * time.Hour))},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should require a timeout or deadline when there is a maximum",
+			timeout:  config.Timeout{Max: config.Duration{Duration: time.Hour}},
+			req:      &jobproto.StartRequest{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should reject negative timeouts",
+			req:      &jobproto.StartRequest{Timeout: durationpb.New(-time.Second)},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should allow jobs without a timeout when there is no maximum",
+			req:      &jobproto.StartRequest{},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &JobServer{Timeout: tt.timeout, Clock: &clock.Application{Location: time.UTC}}
+			options, err := server.getOptions(tt.req)
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("getOptions() code = %s, want %s: %v", code, tt.wantCode, err)
+			}
+
+			if err == nil && options.Timeout != tt.wantTimeout {
+				t.Errorf("getOptions() timeout = %s, want %s", options.Timeout, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestJobServer_validatePriority(t *testing.T) {
+	server := &JobServer{MaxPriority: 5, PriorityIdentities: []string{"admin"}}
+
+	tests := []struct {
+		name     string
+		owner    string
+		req      *jobproto.StartRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "Should allow priorities up to the maximum",
+			owner:    "client",
+			req:      &jobproto.StartRequest{Priority: 5},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should allow negative priorities",
+			owner:    "client",
+			req:      &jobproto.StartRequest{Priority: -10},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should reject priorities above the maximum",
+			owner:    "client",
+			req:      &jobproto.StartRequest{Priority: 6},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should reject preemption",
+			owner:    "client",
+			req:      &jobproto.StartRequest{Preempt: true},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should reject clients without an identity",
+			req:      &jobproto.StartRequest{Preempt: true},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should allow priority identities any priority and preemption",
+			owner:    "admin",
+			req:      &jobproto.StartRequest{Priority: 100, Preempt: true},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(server.validatePriority(tt.owner, tt.req)); got != tt.wantCode {
+				t.Errorf("validatePriority() code = %s, want %s", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRedactStartRequest(t *testing.T) {
+	req := &jobproto.StartRequest{
+		Command: &jobproto.Command{Name: "env"},
+		Env:     map[string]string{"TOKEN": "secret"},
+		Stdin:   []byte("password"),
+	}
+
+	redacted := redactStartRequest(req)
+
+	if redacted.Env["TOKEN"] != redactedValue || string(redacted.Stdin) != redactedValue {
+		t.Errorf("redactStartRequest() env = %v, stdin = %q, want values redacted", redacted.Env, redacted.Stdin)
+	}
+
+	if redacted.Command.GetName() != "env" {
+		t.Errorf("redactStartRequest() command = %s, want env", redacted.Command.GetName())
+	}
+
+	if req.Env["TOKEN"] != "secret" || string(req.Stdin) != "password" {
+		t.Error("redactStartRequest() changed the request")
+	}
+}
@@ -0,0 +1,59 @@
+package serve
+
+import (
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"io"
+)
+
+func (s *JobServer) WriteStdin(stream jobproto.Job_WriteStdinServer) error {
+	logging.Log.Debug("Handling write stdin request")
+
+	var job *jobs.Job
+
+	for {
+		req, err := stream.Recv()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if job == nil {
+			if job, err = s.findJob(req.Id); err != nil {
+				return err
+			}
+		}
+
+		if len(req.Data) > 0 {
+			if err := job.WriteStdin(req.Data); err != nil {
+				logging.Log.Error("Failed to write job stdin", "err", err)
+
+				return toStatusError(err)
+			}
+		}
+
+		if req.Close {
+			if err := job.CloseStdin(); err != nil {
+				logging.Log.Error("Failed to close job stdin", "err", err)
+
+				return toStatusError(err)
+			}
+		}
+	}
+
+	if job == nil {
+		return invalidArgument("id", "a job ID is required")
+	}
+
+	pb := ProtoBuf{}
+
+	return stream.SendAndClose(&jobproto.Response{
+		Info:           pb.toJobInfo(job),
+		ResourceLimits: pb.toResources(job.Limits()),
+	})
+}
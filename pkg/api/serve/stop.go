@@ -9,18 +9,16 @@ import (
 func (s *JobServer) Stop(ctx context.Context, req *jobproto.StopRequest) (*jobproto.Response, error) {
 	logging.Log.Debug("Handling stop job request", "request", req)
 
-	job, ok := s.Jobs[req.Id]
-
-	if !ok {
-		return nil, nil
-	}
-
-	err := job.Stop()
+	job, err := s.findJob(req.Id)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := job.Stop(); err != nil {
+		return nil, toStatusError(err)
+	}
+
 	pb := ProtoBuf{}
 
 	return &jobproto.Response{
@@ -0,0 +1,260 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// jobResourceType Type of resource given in the details of errors about jobs.
+	jobResourceType = "job"
+	// workflowResourceType Type of resource given in the details of errors about workflows.
+	workflowResourceType = "workflow"
+	// scheduleResourceType Type of resource given in the details of errors about schedules.
+	scheduleResourceType = "schedule"
+	// statusViolation Type of precondition violation for requests that cannot be handled with the job's status.
+	statusViolation = "STATUS"
+)
+
+// invalidArgument Returns an InvalidArgument error whose details describe which field of the request is invalid.
+func invalidArgument(field string, description string) error {
+	return withDetails(
+		status.New(codes.InvalidArgument, fmt.Sprintf("invalid %s: %s", field, description)),
+		&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+		},
+	)
+}
+
+// jobNotFound Returns a NotFound error whose details identify the job that doesn't exist.
+func jobNotFound(id string) error {
+	return notFound(jobResourceType, id)
+}
+
+// notFound Returns a NotFound error whose details identify the resource of the given type that doesn't exist.
+func notFound(resourceType string, id string) error {
+	description := fmt.Sprintf("%s does not exist", resourceType)
+
+	return withDetails(
+		status.New(codes.NotFound, fmt.Sprintf("%s: %s", description, id)),
+		&errdetails.ResourceInfo{ResourceType: resourceType, ResourceName: id, Description: description},
+	)
+}
+
+// failedPrecondition Returns a FailedPrecondition error whose details describe why the job is not in a state where the
+// request can be handled.
+func failedPrecondition(id string, description string) error {
+	return withDetails(
+		status.New(codes.FailedPrecondition, description),
+		&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: statusViolation, Subject: id, Description: description},
+			},
+		},
+	)
+}
+
+// withDetails Returns the status as an error with the details attached, or without them if they cannot be attached.
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// toStatusError Convert an error returned by the job library to a gRPC error with a code describing it; errors that are
+// already gRPC errors are returned as is.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var statusErr *jobs.StatusError
+
+	switch {
+	case errors.As(err, &statusErr):
+		return failedPrecondition(statusErr.ID, err.Error())
+	case errors.Is(err, jobs.ErrJobNotRunning), errors.Is(err, jobs.ErrNoTerminal), errors.Is(err, jobs.ErrNoStdinStream),
+		errors.Is(err, jobs.ErrNotStructured), errors.Is(err, jobs.ErrOutputDeleted), errors.Is(err, jobs.ErrWorkflowEnded),
+		errors.Is(err, jobs.ErrWorkflowNotEnded), errors.Is(err, jobs.ErrJobIsolated):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, jobs.ErrInvalidOptions), errors.Is(err, jobs.ErrExecutableNotFound),
+		errors.Is(err, jobs.ErrInvalidWorkflow):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, jobs.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, cgroups.ErrControllerUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, os.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+// findJob Returns the job with the ID after ensuring the ID is valid; the error is a gRPC error.
+func (s *JobServer) findJob(id string) (*jobs.Job, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	job, ok := s.getJob(id)
+
+	if !ok {
+		return nil, jobNotFound(id)
+	}
+
+	return job, nil
+}
+
+// findWorkflow Returns the workflow with the ID after ensuring the ID is valid; the error is a gRPC error.
+func (s *JobServer) findWorkflow(id string) (*jobs.Workflow, error) {
+	if err := validateResourceID(workflowResourceType, id); err != nil {
+		return nil, err
+	}
+
+	workflow, ok := s.getWorkflow(id)
+
+	if !ok {
+		return nil, notFound(workflowResourceType, id)
+	}
+
+	return workflow, nil
+}
+
+// findSchedule Returns the schedule with the ID after ensuring the ID is valid; the error is a gRPC error.
+func (s *JobServer) findSchedule(id string) (*jobs.Schedule, error) {
+	if err := validateResourceID(scheduleResourceType, id); err != nil {
+		return nil, err
+	}
+
+	schedule, ok := s.getSchedule(id)
+
+	if !ok {
+		return nil, notFound(scheduleResourceType, id)
+	}
+
+	return schedule, nil
+}
+
+// validateID Ensure the job ID is a UUID, which every job's ID is.
+func validateID(id string) error {
+	return validateResourceID(jobResourceType, id)
+}
+
+// validateResourceID Ensure the ID of a resource of the given type is a UUID, which the ID of every job, workflow and
+// schedule is.
+func validateResourceID(resourceType string, id string) error {
+	if id == "" {
+		return invalidArgument("id", fmt.Sprintf("a %s ID is required", resourceType))
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return invalidArgument("id", fmt.Sprintf("%s ID must be a UUID: %s", resourceType, id))
+	}
+
+	return nil
+}
+
+// validateCommand Ensure the command has a name that resolves to an executable. Names without a slash are looked up in
+// the server's PATH and relative paths are resolved against the working directory, or the server's if it is empty.
+func validateCommand(field string, command *jobproto.Command, dir string) error {
+	if command == nil || strings.TrimSpace(command.Name) == "" {
+		return invalidArgument(field+".name", "a command is required")
+	}
+
+	name := command.Name
+
+	if strings.Contains(name, "/") && !filepath.IsAbs(name) && dir != "" {
+		name = filepath.Join(dir, name)
+	}
+
+	if _, err := exec.LookPath(name); err != nil {
+		return invalidArgument(field+".name", fmt.Sprintf("command is not an executable: %s", command.Name))
+	}
+
+	return nil
+}
+
+// validateStartRequest Ensure the request describes a job that can be started; resource limits and timeouts are
+// validated against the server's limits when they are applied.
+func validateStartRequest(req *jobproto.StartRequest) error {
+	if req.WorkingDir != "" {
+		if !filepath.IsAbs(req.WorkingDir) {
+			return invalidArgument("working_dir", fmt.Sprintf("must be an absolute path: %s", req.WorkingDir))
+		}
+
+		if info, err := os.Stat(req.WorkingDir); err != nil || !info.IsDir() {
+			return invalidArgument("working_dir", fmt.Sprintf("not a directory: %s", req.WorkingDir))
+		}
+	}
+
+	if err := validateCommand("command", req.Command, req.WorkingDir); err != nil {
+		return err
+	}
+
+	for key := range req.Env {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return invalidArgument("env", fmt.Sprintf("invalid environment variable name: %q", key))
+		}
+	}
+
+	if err := jobs.ValidateLabels(req.Labels); err != nil {
+		return invalidArgument("labels", err.Error())
+	}
+
+	if req.Umask != nil && *req.Umask > 0777 {
+		return invalidArgument("umask", fmt.Sprintf("must be at most 0777: %#o", *req.Umask))
+	}
+
+	if len(req.Stdin) > 0 && req.StdinStream {
+		return invalidArgument("stdin", "stdin data cannot be used along with stdin_stream")
+	}
+
+	if req.Tty && (len(req.Stdin) > 0 || req.StdinStream) {
+		return invalidArgument("tty", "a terminal cannot be used along with stdin or stdin_stream")
+	}
+
+	if _, ok := jobproto.OutputFormat_name[int32(req.OutputFormat)]; !ok {
+		return invalidArgument("output_format", fmt.Sprintf("unknown output format: %d", req.OutputFormat))
+	}
+
+	if policy := req.RestartPolicy; policy != nil {
+		if _, ok := jobproto.RestartMode_name[int32(policy.Mode)]; !ok {
+			return invalidArgument("restart_policy.mode", fmt.Sprintf("unknown restart mode: %d", policy.Mode))
+		}
+
+		if policy.MaxRetries < 0 {
+			return invalidArgument("restart_policy.max_retries", fmt.Sprintf("cannot be negative: %d", policy.MaxRetries))
+		}
+
+		if policy.InitialBackoff.AsDuration() < 0 || policy.MaxBackoff.AsDuration() < 0 {
+			return invalidArgument("restart_policy", "backoff cannot be negative")
+		}
+	}
+
+	if req.Deadline != nil && !req.Deadline.IsValid() {
+		return invalidArgument("deadline", "not a valid time")
+	}
+
+	return nil
+}
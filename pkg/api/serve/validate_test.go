@@ -0,0 +1,214 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"testing"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{
+			name:     "Should keep nil errors",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should keep gRPC errors",
+			err:      status.Error(codes.PermissionDenied, "denied"),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "Should map status errors to failed precondition",
+			err:      &jobs.StatusError{ID: "some-id", Op: "stop", Status: jobs.SucceededStatus, Err: jobs.ErrJobEnded},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "Should map wrapped errors of jobs that aren't running to failed precondition",
+			err:      fmt.Errorf("signal: %w", jobs.ErrJobNotRunning),
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "Should map isolated jobs to failed precondition",
+			err:      fmt.Errorf("%w: cannot execute command in job", jobs.ErrJobIsolated),
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "Should map invalid options to invalid argument",
+			err:      fmt.Errorf("%w: umask", jobs.ErrInvalidOptions),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Should map exceeded quotas to resource exhausted",
+			err:      jobs.ErrQuotaExceeded,
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:     "Should map unavailable controllers to unavailable",
+			err:      cgroups.ErrControllerUnavailable,
+			wantCode: codes.Unavailable,
+		},
+		{
+			name:     "Should map missing files to not found",
+			err:      &os.PathError{Op: "open", Path: "/missing", Err: os.ErrNotExist},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "Should map other errors to internal",
+			err:      errors.New("something went wrong"),
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(toStatusError(tt.err)); got != tt.wantCode {
+				t.Errorf("toStatusError() code = %s, want %s", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestToStatusError_details(t *testing.T) {
+	err := toStatusError(&jobs.StatusError{ID: "some-id", Op: "stop", Status: jobs.SucceededStatus, Err: jobs.ErrJobEnded})
+
+	var violation *errdetails.PreconditionFailure_Violation
+
+	for _, detail := range status.Convert(err).Details() {
+		if failure, ok := detail.(*errdetails.PreconditionFailure); ok && len(failure.Violations) == 1 {
+			violation = failure.Violations[0]
+		}
+	}
+
+	if violation == nil {
+		t.Fatalf("toStatusError() details = %v, want a precondition failure", status.Convert(err).Details())
+	}
+
+	if violation.Type != statusViolation || violation.Subject != "some-id" {
+		t.Errorf("toStatusError() violation = %s %s, want %s some-id", violation.Type, violation.Subject, statusViolation)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	id := uuid.NewString()
+	err := notFound(workflowResourceType, id)
+
+	if code := status.Code(err); code != codes.NotFound {
+		t.Errorf("notFound() code = %s, want %s", code, codes.NotFound)
+	}
+
+	details := status.Convert(err).Details()
+
+	if len(details) != 1 {
+		t.Fatalf("notFound() details = %v, want one", details)
+	}
+
+	if info, ok := details[0].(*errdetails.ResourceInfo); !ok ||
+		info.ResourceType != workflowResourceType || info.ResourceName != id {
+		t.Errorf("notFound() details = %v, want resource info of workflow %s", details[0], id)
+	}
+}
+
+func TestValidateResourceID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{
+			name: "Should accept UUIDs",
+			id:   uuid.NewString(),
+		},
+		{
+			name:    "Should reject empty IDs",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject IDs that aren't UUIDs",
+			id:      "../some-id",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResourceID(scheduleResourceType, tt.id)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateResourceID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("validateResourceID() code = %s, want %s", status.Code(err), codes.InvalidArgument)
+			}
+		})
+	}
+}
+
+func TestValidateStartRequest(t *testing.T) {
+	command := &jobproto.Command{Name: "true"}
+	umask := uint32(01000)
+
+	tests := []struct {
+		name    string
+		req     *jobproto.StartRequest
+		wantErr bool
+	}{
+		{
+			name: "Should accept a command in the PATH",
+			req:  &jobproto.StartRequest{Command: command},
+		},
+		{
+			name:    "Should require a command",
+			req:     &jobproto.StartRequest{},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject commands that aren't executables",
+			req:     &jobproto.StartRequest{Command: &jobproto.Command{Name: "/nonexistent/command"}},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject relative working directories",
+			req:     &jobproto.StartRequest{Command: command, WorkingDir: "tmp"},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject invalid environment variable names",
+			req:     &jobproto.StartRequest{Command: command, Env: map[string]string{"A=B": "value"}},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject umasks with more than the permission bits",
+			req:     &jobproto.StartRequest{Command: command, Umask: &umask},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject a terminal along with stdin",
+			req:     &jobproto.StartRequest{Command: command, Tty: true, StdinStream: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStartRequest(tt.req)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateStartRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("validateStartRequest() code = %s, want %s", status.Code(err), codes.InvalidArgument)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *JobServer) SubmitWorkflow(
+	ctx context.Context, req *jobproto.SubmitWorkflowRequest,
+) (*jobproto.WorkflowResponse, error) {
+	logging.Log.Debug("Handling submit workflow request", "request", redactWorkflowRequest(req))
+
+	owner, _ := identity(ctx)
+	steps, err := s.getWorkflowSteps(owner, req.Steps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make(map[string]*jobproto.StartRequest, len(req.Steps))
+
+	for _, step := range req.Steps {
+		requests[step.Name] = step.Job
+	}
+
+	// The job of each step is checked against the server's limits and the client's quota again once it is started
+	start := func(name string) (*jobs.Job, error) {
+		return s.startJob(owner, requests[name])
+	}
+
+	workflow, err := jobs.NewWorkflow(req.Name, owner, s.Clock, steps, start)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	s.addWorkflow(workflow)
+	workflow.Run()
+
+	pb := ProtoBuf{}
+
+	return &jobproto.WorkflowResponse{Info: pb.toWorkflowInfo(workflow)}, nil
+}
+
+func (s *JobServer) QueryWorkflow(
+	ctx context.Context, req *jobproto.QueryWorkflowRequest,
+) (*jobproto.WorkflowResponse, error) {
+	logging.Log.Debug("Handling query workflow request", "request", req)
+
+	workflow, err := s.findWorkflow(req.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pb := ProtoBuf{}
+
+	return &jobproto.WorkflowResponse{Info: pb.toWorkflowInfo(workflow)}, nil
+}
+
+func (s *JobServer) CancelWorkflow(
+	ctx context.Context, req *jobproto.CancelWorkflowRequest,
+) (*jobproto.WorkflowResponse, error) {
+	logging.Log.Debug("Handling cancel workflow request", "request", req)
+
+	workflow, err := s.findWorkflow(req.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := workflow.Cancel(); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	pb := ProtoBuf{}
+
+	return &jobproto.WorkflowResponse{Info: pb.toWorkflowInfo(workflow)}, nil
+}
+
+func (s *JobServer) DeleteWorkflow(
+	ctx context.Context, req *jobproto.DeleteWorkflowRequest,
+) (*jobproto.WorkflowResponse, error) {
+	logging.Log.Debug("Handling delete workflow request", "request", req)
+
+	workflow, err := s.findWorkflow(req.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ended := workflow.Ended(); !ended {
+		return nil, toStatusError(
+			fmt.Errorf("%w: cannot delete workflow with status %s", jobs.ErrWorkflowNotEnded, workflow.Status()),
+		)
+	}
+
+	s.removeWorkflow(workflow)
+
+	pb := ProtoBuf{}
+
+	return &jobproto.WorkflowResponse{Info: pb.toWorkflowInfo(workflow)}, nil
+}
+
+// redactWorkflowRequest Returns a copy of the request that can be logged, with the job of each step redacted.
+func redactWorkflowRequest(req *jobproto.SubmitWorkflowRequest) *jobproto.SubmitWorkflowRequest {
+	redacted := proto.Clone(req).(*jobproto.SubmitWorkflowRequest)
+
+	for _, step := range redacted.Steps {
+		step.Job = redactStartRequest(step.Job)
+	}
+
+	return redacted
+}
+
+// getWorkflowSteps Get the steps of the client's workflow from the request, ensuring that the job of every step could be
+// started and that the steps form a DAG.
+func (s *JobServer) getWorkflowSteps(owner string, req []*jobproto.WorkflowStep) ([]jobs.Step, error) {
+	steps := make([]jobs.Step, 0, len(req))
+
+	for i, step := range req {
+		if step.Job == nil {
+			return nil, invalidArgument(fmt.Sprintf("steps[%d].job", i), "a job is required")
+		}
+
+		if err := s.validateJobRequest(owner, step.Job); err != nil {
+			return nil, inStep(step.Name, err)
+		}
+
+		steps = append(steps, jobs.Step{Name: step.Name, DependsOn: step.DependsOn})
+	}
+
+	if err := jobs.ValidateSteps(steps); err != nil {
+		return nil, invalidArgument("steps", err.Error())
+	}
+
+	return steps, nil
+}
+
+// inStep Prefix the message of a gRPC error about the job of a step with the step's name, keeping its details.
+func inStep(name string, err error) error {
+	pb := status.Convert(err).Proto()
+	pb.Message = fmt.Sprintf("step %s: %s", name, pb.Message)
+
+	return status.ErrorProto(pb)
+}
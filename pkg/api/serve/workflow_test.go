@@ -0,0 +1,139 @@
+package serve
+
+import (
+	"context"
+	"github.com/google/uuid"
+	jobproto "github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+// newEndedJobs Returns jobs that have already succeeded, restored from records so that they don't need a cgroup.
+func newEndedJobs(t *testing.T, count int) []*jobs.Job {
+	var records []jobs.Record
+
+	for i := 0; i < count; i++ {
+		records = append(records, jobs.Record{ID: uuid.NewString(), Status: jobs.SucceededStatus, OutputDir: t.TempDir()})
+	}
+
+	reconciliation := jobs.Reconciliation{
+		CgroupRoot: t.TempDir(),
+		WorkerName: "worker",
+		Clock:      &clock.Application{Location: time.UTC},
+	}
+
+	restored, err := reconciliation.Restore(records)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return restored
+}
+
+// newWorkflow Returns a workflow whose steps are given the jobs in order, added to the server along with the jobs.
+func newWorkflow(t *testing.T, server *JobServer, stepJobs []*jobs.Job) *jobs.Workflow {
+	steps := make([]jobs.Step, len(stepJobs))
+	byName := make(map[string]*jobs.Job, len(stepJobs))
+
+	for i, job := range stepJobs {
+		steps[i] = jobs.Step{Name: job.ID()}
+		byName[job.ID()] = job
+		server.addJob(job)
+	}
+
+	start := func(name string) (*jobs.Job, error) {
+		return byName[name], nil
+	}
+
+	workflow, err := jobs.NewWorkflow("", "", server.Clock, steps, start)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.addWorkflow(workflow)
+
+	return workflow
+}
+
+func newWorkflowServer() *JobServer {
+	return &JobServer{
+		Clock:     &clock.Application{Location: time.UTC},
+		Retention: jobs.NewRetention(jobs.RetentionPolicy{}, &clock.Application{Location: time.UTC}),
+		Jobs:      make(map[string]*jobs.Job),
+		Workflows: make(map[string]*jobs.Workflow),
+	}
+}
+
+func TestJobServer_DeleteWorkflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      bool
+		wantCode codes.Code
+	}{
+		{
+			name:     "Should delete ended workflows",
+			run:      true,
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should not delete workflows that haven't ended",
+			wantCode: codes.FailedPrecondition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWorkflowServer()
+			stepJobs := newEndedJobs(t, 1)
+			workflow := newWorkflow(t, server, stepJobs)
+
+			if tt.run {
+				workflow.Run()
+			}
+
+			_, err := server.DeleteWorkflow(context.Background(), &jobproto.DeleteWorkflowRequest{Id: workflow.ID()})
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("DeleteWorkflow() code = %s, want %s: %v", code, tt.wantCode, err)
+			}
+
+			_, found := server.getWorkflow(workflow.ID())
+
+			if found != (err != nil) {
+				t.Errorf("workflow found after DeleteWorkflow() = %t, want %t", found, err != nil)
+			}
+
+			if _, ok := server.getJob(stepJobs[0].ID()); !ok {
+				t.Error("DeleteWorkflow() deleted the job of a step")
+			}
+		})
+	}
+}
+
+func TestJobServer_evictWorkflows(t *testing.T) {
+	server := newWorkflowServer()
+	stepJobs := newEndedJobs(t, 2)
+	workflow := newWorkflow(t, server, stepJobs)
+	workflow.Run()
+
+	if _, ended := workflow.Ended(); !ended {
+		t.Fatal("workflow did not end")
+	}
+
+	server.removeJob(stepJobs[0])
+
+	if _, ok := server.getWorkflow(workflow.ID()); !ok {
+		t.Error("workflow was removed while one of its jobs remains")
+	}
+
+	server.removeJob(stepJobs[1])
+
+	if _, ok := server.getWorkflow(workflow.ID()); ok {
+		t.Error("workflow was not removed after all of its jobs were deleted")
+	}
+}
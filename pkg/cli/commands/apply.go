@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type ApplyCmd struct {
+	client job.JobClient
+
+	requests []*job.StartRequest
+}
+
+// jobSpec Job described by a YAML manifest; resource limits and the timeout that aren't set use the server's defaults.
+type jobSpec struct {
+	Command        string            `yaml:"command"`
+	Args           []string          `yaml:"args"`
+	Env            map[string]string `yaml:"env"`
+	WorkingDir     string            `yaml:"working_dir"`
+	Timeout        time.Duration     `yaml:"timeout"`
+	Priority       int32             `yaml:"priority"`
+	Labels         map[string]string `yaml:"labels"`
+	ResourceLimits struct {
+		MemoryBytes   *uint64 `yaml:"memory_bytes"`
+		CPUPercentage *int32  `yaml:"cpu_percentage"`
+		DiskIOBPS     *int32  `yaml:"disk_io_bps"`
+	} `yaml:"resource_limits"`
+	Isolation struct {
+		PID     bool `yaml:"pid"`
+		Mount   bool `yaml:"mount"`
+		Network bool `yaml:"network"`
+	} `yaml:"isolation"`
+}
+
+func (s *ApplyCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *ApplyCmd) ParseCLI(set *flag.FlagSet) error {
+	fileArg := set.String("f", "", "YAML manifest describing the jobs to start, one per document; - reads stdin")
+
+	if err := parseOSArgs(set); err != nil {
+		return err
+	}
+
+	if *fileArg == "" {
+		return errors.New("a manifest file must be specified with -f")
+	}
+
+	var reader io.Reader = os.Stdin
+
+	if *fileArg != "-" {
+		file, err := os.Open(*fileArg)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		reader = file
+	}
+
+	requests, err := readManifest(reader)
+
+	if err != nil {
+		return fmt.Errorf("invalid manifest %s: %w", *fileArg, err)
+	}
+
+	s.requests = requests
+
+	return nil
+}
+
+func (s *ApplyCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	failed := false
+
+	fmt.Fprintln(w, "DOCUMENT\tJOB\tCOMMAND\tERROR")
+
+	// Every job is started even if an earlier one fails so that the output shows which ones need to be fixed
+	for i, req := range s.requests {
+		command := strings.Join(append([]string{req.Command.GetName()}, req.Command.GetArgs()...), " ")
+		resp, err := s.client.Start(ctx, req)
+
+		if err != nil {
+			failed = true
+
+			fmt.Fprintf(w, "%d\t-\t%s\t%s\n", i+1, command, err)
+
+			continue
+		}
+
+		logging.Log.Debug("Start response", "response", resp)
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t-\n", i+1, resp.Info.ID, command)
+	}
+
+	w.Flush()
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readManifest Read every document of the YAML manifest as a request to start a job; empty documents are ignored.
+// Unknown fields are errors so that misspelled fields aren't silently left out.
+func readManifest(reader io.Reader) ([]*job.StartRequest, error) {
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+
+	var requests []*job.StartRequest
+
+	for document := 1; ; document++ {
+		// Empty documents leave the spec nil
+		var spec *jobSpec
+
+		if err := decoder.Decode(&spec); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("document %d: %w", document, err)
+		}
+
+		if spec == nil {
+			continue
+		}
+
+		if strings.TrimSpace(spec.Command) == "" {
+			return nil, fmt.Errorf("document %d: a command is required", document)
+		}
+
+		requests = append(requests, spec.startRequest())
+	}
+
+	if len(requests) == 0 {
+		return nil, errors.New("no jobs are described")
+	}
+
+	return requests, nil
+}
+
+// startRequest Returns the request to start the described job.
+func (s jobSpec) startRequest() *job.StartRequest {
+	req := &job.StartRequest{
+		Command:    &job.Command{Name: s.Command, Args: s.Args},
+		Env:        s.Env,
+		WorkingDir: s.WorkingDir,
+		Priority:   s.Priority,
+		Labels:     s.Labels,
+		ResourceLimits: &job.Resources{
+			MemoryBytes:   s.ResourceLimits.MemoryBytes,
+			CpuPercentage: s.ResourceLimits.CPUPercentage,
+			DiskIoBps:     s.ResourceLimits.DiskIOBPS,
+		},
+	}
+
+	if s.Timeout != 0 {
+		req.Timeout = durationpb.New(s.Timeout)
+	}
+
+	if s.Isolation.PID || s.Isolation.Mount || s.Isolation.Network {
+		req.Isolation = &job.Isolation{Pid: s.Isolation.PID, Mount: s.Isolation.Mount, Network: s.Isolation.Network}
+	}
+
+	return req
+}
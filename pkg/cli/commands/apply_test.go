@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name         string
+		manifest     string
+		wantCommands []string
+		wantErr      bool
+	}{
+		{
+			name:         "Should read every document",
+			manifest:     "command: echo\nargs: [hello]\n---\ncommand: sleep\nargs: [\"1\"]\n",
+			wantCommands: []string{"echo", "sleep"},
+		},
+		{
+			name:         "Should skip empty documents",
+			manifest:     "---\ncommand: echo\n---\n---\n# only a comment\n",
+			wantCommands: []string{"echo"},
+		},
+		{
+			name:     "Should reject unknown fields",
+			manifest: "command: echo\nargz: [hello]\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Should require a command",
+			manifest: "command: echo\n---\nargs: [hello]\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Should require a job",
+			manifest: "---\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Should reject invalid YAML",
+			manifest: "command: [echo\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, err := readManifest(strings.NewReader(tt.manifest))
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+
+			for _, req := range requests {
+				got = append(got, req.Command.GetName())
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.wantCommands, ",") {
+				t.Errorf("readManifest() commands = %v, want %v", got, tt.wantCommands)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+type AttachCmd struct {
+	client job.JobClient
+
+	jobID string
+	tty   bool
+}
+
+func (s *AttachCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *AttachCmd) ParseCLI(set *flag.FlagSet) error {
+	idArg := set.String("id", "", "ID of the job to attach to")
+	ttyArg := set.Bool("t", false, "put this terminal into raw mode and forward its size to the job's terminal")
+
+	if err := parseOSArgs(set); err != nil {
+		return err
+	}
+
+	s.jobID = *idArg
+	s.tty = *ttyArg
+
+	return nil
+}
+
+func (s *AttachCmd) Run() {
+	// The job is attached to for an arbitrary amount of time so there is no timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := s.client.Attach(ctx)
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	stdinFD := int(os.Stdin.Fd())
+
+	if s.tty && isTerminal(stdinFD) {
+		restore, err := makeRaw(stdinFD)
+
+		if err != nil {
+			logging.Log.Error("Failed to put terminal into raw mode", "err", err)
+
+			os.Exit(1)
+		}
+
+		defer restore()
+	}
+
+	if err := stream.Send(&job.AttachRequest{Id: s.jobID, Resize: s.windowSize()}); err != nil {
+		logging.Log.Error("Failed to attach to job", "err", err)
+
+		return
+	}
+
+	if s.tty {
+		go s.forwardResizes(ctx, stream)
+	}
+
+	go forwardStdin(stream)
+
+	for {
+		resp, err := stream.Recv()
+
+		if err == io.EOF {
+			return
+		}
+
+		if err != nil {
+			logging.Log.Error("Failed to read stream", "err", err)
+
+			return
+		}
+
+		os.Stdout.Write(resp.Output)
+	}
+}
+
+// windowSize Get the size of this terminal; nil if the size isn't forwarded to the job.
+func (s *AttachCmd) windowSize() *job.WindowSize {
+	if !s.tty {
+		return nil
+	}
+
+	size := getWindowSize(int(os.Stdout.Fd()))
+
+	if size == nil {
+		return nil
+	}
+
+	return &job.WindowSize{Rows: uint32(size.Row), Cols: uint32(size.Col)}
+}
+
+// forwardResizes Send the size of this terminal to the job whenever it changes.
+func (s *AttachCmd) forwardResizes(ctx context.Context, stream job.Job_AttachClient) {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+
+	defer signal.Stop(resized)
+
+	for {
+		select {
+		case <-resized:
+			if size := s.windowSize(); size != nil {
+				if err := stream.Send(&job.AttachRequest{Resize: size}); err != nil {
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// forwardStdin Send everything from this command's stdin to the job's terminal.
+func forwardStdin(stream job.Job_AttachClient) {
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := os.Stdin.Read(buf)
+
+		if n > 0 {
+			if err := stream.Send(&job.AttachRequest{Stdin: buf[:n]}); err != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			_ = stream.CloseSend()
+
+			return
+		}
+	}
+}
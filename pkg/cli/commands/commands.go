@@ -4,14 +4,25 @@ import (
 	"flag"
 	"github.com/kurczynski/teleport-job-worker/api/proto/job"
 	"os"
+	"strings"
 	"time"
 )
 
 const (
-	Start  = "start"
-	Stop   = "stop"
-	Query  = "query"
-	Output = "output"
+	Start    = "start"
+	Stop     = "stop"
+	Query    = "query"
+	List     = "list"
+	Quota    = "quota"
+	Output   = "output"
+	Signal   = "signal"
+	Attach   = "attach"
+	Exec     = "exec"
+	Search   = "grep"
+	Delete   = "rm"
+	Workflow = "workflow"
+	Schedule = "schedule"
+	Apply    = "apply"
 
 	DefaultCtxTimeout = 10 * time.Second
 )
@@ -29,3 +40,16 @@ type Command interface {
 func parseOSArgs(set *flag.FlagSet) error {
 	return set.Parse(os.Args[2:])
 }
+
+// stringsFlag Flag that can be specified multiple times, collecting every value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+
+	return nil
+}
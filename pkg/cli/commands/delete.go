@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"os"
+)
+
+type DeleteCmd struct {
+	client job.JobClient
+
+	jobID string
+}
+
+func (s *DeleteCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *DeleteCmd) ParseCLI(set *flag.FlagSet) error {
+	idArg := set.String("id", "", "ID of the finished job to delete along with its output")
+
+	if err := parseOSArgs(set); err != nil {
+		return err
+	}
+
+	s.jobID = *idArg
+
+	return nil
+}
+
+func (s *DeleteCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	resp, err := s.client.Delete(ctx, &job.DeleteRequest{Id: s.jobID})
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	fmt.Println(resp.String())
+
+	logging.Log.Debug("Delete response", "response", resp)
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"io"
+	"os"
+)
+
+type ExecCmd struct {
+	client job.JobClient
+
+	jobID   string
+	command string
+	args    []string
+}
+
+func (s *ExecCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *ExecCmd) ParseCLI(set *flag.FlagSet) error {
+	idArg := set.String("id", "", "ID of the job to execute the command in")
+
+	if err := parseOSArgs(set); err != nil {
+		return err
+	}
+
+	// Everything after the flags (and an optional "--") is the command to execute
+	if set.NArg() == 0 {
+		return errors.New("a command must be specified, e.g. exec -id some-job-id -- ps aux")
+	}
+
+	s.jobID = *idArg
+	s.command = set.Arg(0)
+	s.args = set.Args()[1:]
+
+	return nil
+}
+
+func (s *ExecCmd) Run() {
+	// The command can run for an arbitrary amount of time so there is no timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := s.client.Exec(ctx, &job.ExecRequest{
+		Id:      s.jobID,
+		Command: &job.Command{Name: s.command, Args: s.args},
+	})
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	for {
+		resp, err := stream.Recv()
+
+		if err == io.EOF {
+			return
+		}
+
+		if err != nil {
+			logging.Log.Error("Failed to read stream", "err", err)
+
+			os.Exit(1)
+		}
+
+		os.Stdout.Write(resp.Stdout)
+		os.Stderr.Write(resp.Stderr)
+
+		if resp.ExitCode != nil {
+			os.Exit(int(*resp.ExitCode))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type ListCmd struct {
+	client job.JobClient
+}
+
+func (s *ListCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *ListCmd) ParseCLI(set *flag.FlagSet) error {
+	return parseOSArgs(set)
+}
+
+func (s *ListCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	resp, err := s.client.List(ctx, &job.ListRequest{})
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	printJobs(resp.Jobs)
+
+	logging.Log.Debug("List response", "response", resp)
+}
+
+// printJobs Print a summary of each job as a table; jobs that aren't queued have no queue position.
+func printJobs(jobs []*job.Info) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ID\tSTATUS\tPRIORITY\tQUEUE\tCREATED\tCOMMAND")
+
+	for _, info := range jobs {
+		position := "-"
+
+		if info.QueuePosition > 0 {
+			position = fmt.Sprint(info.QueuePosition)
+		}
+
+		command := strings.Join(append([]string{info.Command.GetName()}, info.Command.GetArgs()...), " ")
+
+		fmt.Fprintf(
+			w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			info.ID, info.Status, info.Priority, position, info.Created.AsTime().Format(time.RFC3339), command,
+		)
+	}
+
+	w.Flush()
+}
@@ -6,13 +6,21 @@ import (
 	"fmt"
 	"github.com/kurczynski/teleport-job-worker/api/proto/job"
 	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
 	"os"
+	"time"
 )
 
 type OutputCmd struct {
 	client job.JobClient
 
-	jobID string
+	jobID     string
+	tailLines int64
+	offset    int64
+	since     time.Time
+	filters   []string
+	printer   jsonPrinter
 }
 
 func (s *OutputCmd) SetClient(client job.JobClient) {
@@ -21,12 +29,34 @@ func (s *OutputCmd) SetClient(client job.JobClient) {
 
 func (s *OutputCmd) ParseCLI(set *flag.FlagSet) error {
 	idArg := set.String("id", "", "ID of the job to query")
+	tailArg := set.Int64("n", 0, "output only the last number of lines; zero outputs every line")
+	sinceArg := set.String("since", "", "output only what was written since a time in RFC 3339 format, e.g. 2024-06-01T15:04:05Z, or a duration ago, e.g. 10m")
+	offsetArg := set.Int64("offset", 0, "byte offset to start the output at")
+	printerArgs := parseJSONPrinterArgs(set)
+
+	var filterArg stringsFlag
+
+	set.Var(&filterArg, "filter", "output only lines of JSON output whose field matches in the form field=value, e.g. level=error; can be specified multiple times")
 
 	if err := parseOSArgs(set); err != nil {
 		return err
 	}
 
+	if *sinceArg != "" {
+		since, err := parseSince(*sinceArg)
+
+		if err != nil {
+			return err
+		}
+
+		s.since = since
+	}
+
 	s.jobID = *idArg
+	s.tailLines = *tailArg
+	s.offset = *offsetArg
+	s.filters = filterArg
+	s.printer = printerArgs()
 
 	return nil
 }
@@ -35,7 +65,18 @@ func (s *OutputCmd) Run() {
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
 	defer cancel()
 
-	stream, err := s.client.Output(ctx, &job.OutputRequest{Id: s.jobID})
+	req := &job.OutputRequest{
+		Id:        s.jobID,
+		TailLines: s.tailLines,
+		Offset:    s.offset,
+		Filters:   s.filters,
+	}
+
+	if !s.since.IsZero() {
+		req.Since = timestamppb.New(s.since)
+	}
+
+	stream, err := s.client.Output(ctx, req)
 
 	if err != nil {
 		fmt.Println(err)
@@ -43,15 +84,44 @@ func (s *OutputCmd) Run() {
 		os.Exit(1)
 	}
 
+	writer := &lineWriter{w: os.Stdout, printer: s.printer}
+
 	for {
 		out, err := stream.Recv()
 
+		if err == io.EOF {
+			if err := writer.flush(); err != nil {
+				logging.Log.Error("Failed to write output", "err", err)
+
+				os.Exit(1)
+			}
+
+			return
+		}
+
 		if err != nil {
 			logging.Log.Error("Failed to read stream", "err", err)
 
 			return
 		}
 
-		fmt.Printf("%s", string(out.Stdout))
+		if s.printer.enabled() {
+			if _, err := writer.Write(out.Stdout); err != nil {
+				logging.Log.Error("Failed to write output", "err", err)
+
+				os.Exit(1)
+			}
+		} else {
+			fmt.Printf("%s", string(out.Stdout))
+		}
+	}
+}
+
+// parseSince Parse a time in RFC 3339 format or a duration that is subtracted from the current time.
+func parseSince(since string) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
 	}
+
+	return time.Parse(time.RFC3339, since)
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/kurczynski/teleport-job-worker/api/proto/job"
 	"github.com/kurczynski/teleport-job-worker/internal/logging"
 	"os"
+	"text/tabwriter"
+	"time"
 )
 
 type QueryCmd struct {
@@ -45,5 +47,34 @@ func (s *QueryCmd) Run() {
 
 	fmt.Println(resp)
 
+	if len(resp.Info.Runs) > 0 {
+		printRuns(resp.Info.Runs)
+	}
+
 	logging.Log.Debug("Query response", "response", resp)
 }
+
+// printRuns Print the attempt history of a job as a table.
+func printRuns(runs []*job.Run) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ATTEMPT\tSTARTED\tENDED\tEXIT CODE\tSTDOUT BYTES\tSTDERR BYTES")
+
+	for _, run := range runs {
+		ended := "-"
+		exitCode := "-"
+
+		if run.EndedAt != nil {
+			ended = run.EndedAt.AsTime().Format(time.RFC3339)
+			exitCode = fmt.Sprint(run.ExitCode)
+		}
+
+		fmt.Fprintf(
+			w, "%d\t%s\t%s\t%s\t%d\t%d\n",
+			run.Attempt, run.StartedAt.AsTime().Format(time.RFC3339), ended, exitCode,
+			run.Stdout.GetLength(), run.Stderr.GetLength(),
+		)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"os"
+	"text/tabwriter"
+)
+
+type QuotaCmd struct {
+	client job.JobClient
+}
+
+func (s *QuotaCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *QuotaCmd) ParseCLI(set *flag.FlagSet) error {
+	return parseOSArgs(set)
+}
+
+func (s *QuotaCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	resp, err := s.client.Quota(ctx, &job.QuotaRequest{})
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	fmt.Printf("Identity: %s\n", resp.Identity)
+
+	if resp.Limits == nil {
+		fmt.Println("No quota")
+	}
+
+	printQuota(resp.Limits, resp.Usage)
+
+	logging.Log.Debug("Quota response", "response", resp)
+}
+
+// printQuota Print the resources used by the client's jobs next to their limits as a table; limits that aren't set are
+// shown as unlimited.
+func printQuota(limits *job.QuotaLimits, usage *job.QuotaUsage) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "RESOURCE\tUSED\tLIMIT")
+
+	rows := []struct {
+		name  string
+		used  any
+		limit int64
+	}{
+		{"active jobs", usage.GetJobs(), int64(limits.GetMaxJobs())},
+		{"cpu percentage", usage.GetCpuPercentage(), limits.GetMaxCpuPercentage()},
+		{"memory bytes", usage.GetMemoryBytes(), int64(limits.GetMaxMemoryBytes())},
+		{"cpu percentage per job", "-", limits.GetMaxJobCpuPercentage()},
+		{"memory bytes per job", "-", int64(limits.GetMaxJobMemoryBytes())},
+		{"output bytes", usage.GetOutputBytes(), limits.GetMaxOutputBytes()},
+	}
+
+	for _, row := range rows {
+		limit := "unlimited"
+
+		if row.limit > 0 {
+			limit = fmt.Sprint(row.limit)
+		}
+
+		fmt.Fprintf(w, "%s\t%v\t%s\n", row.name, row.used, limit)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// CreateSchedule Create a schedule that starts a job every time its cron expression matches.
+	CreateSchedule = "create"
+	// ListSchedules Print every schedule.
+	ListSchedules = "list"
+	// DeleteSchedule Delete a schedule; jobs it started keep running.
+	DeleteSchedule = "delete"
+)
+
+type ScheduleCmd struct {
+	client job.JobClient
+
+	action     string
+	scheduleID string
+	request    *job.CreateScheduleRequest
+}
+
+func (s *ScheduleCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *ScheduleCmd) ParseCLI(set *flag.FlagSet) error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf(
+			"a schedule action must be specified; options are: %s, %s, %s", CreateSchedule, ListSchedules,
+			DeleteSchedule,
+		)
+	}
+
+	s.action = os.Args[2]
+
+	switch s.action {
+	case CreateSchedule:
+		return s.parseCreate(set)
+	case ListSchedules:
+		return set.Parse(os.Args[3:])
+	case DeleteSchedule:
+		set.StringVar(&s.scheduleID, "id", "", "ID of the schedule")
+
+		return set.Parse(os.Args[3:])
+	}
+
+	return fmt.Errorf(
+		"invalid schedule action: %s; options are: %s, %s, %s", s.action, CreateSchedule, ListSchedules, DeleteSchedule,
+	)
+}
+
+// parseCreate Parse the arguments of the create action as a request to create a schedule.
+func (s *ScheduleCmd) parseCreate(set *flag.FlagSet) error {
+	nameArg := set.String("name", "", "human-readable name of the schedule")
+	cronArg := set.String("cron", "", "cron expression of when jobs are started, e.g. \"*/15 * * * *\" or @daily")
+	timezoneArg := set.String("timezone", "", "IANA time zone the cron expression is matched in, e.g. America/New_York; uses UTC if unset")
+	policyArg := set.String("policy", "allow", "what to do when a job started earlier is still running; one of: allow, forbid, replace")
+	jobCommandArg := set.String("command", "", "job command to run")
+	argsArg := set.String("args", "", "arguments for the job command")
+	workingDirArg := set.String("workdir", "", "working directory of the job command; uses the server's working directory if unset")
+	timeoutArg := set.Duration("timeout", 0, "maximum amount of time the job command can run, e.g. 1h30m; uses the server's default if unset")
+	priorityArg := set.Int("priority", 0, "order in which the jobs are started when queued; higher priorities start first")
+
+	var envArg stringsFlag
+
+	set.Var(&envArg, "env", "environment variable of the job command in the form KEY=value; can be specified multiple times")
+
+	if err := set.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+
+	policy, ok := job.ConcurrencyPolicy_value[strings.ToUpper(*policyArg)]
+
+	if !ok {
+		return fmt.Errorf("invalid concurrency policy: %s; options are: allow, forbid, replace", *policyArg)
+	}
+
+	env := make(map[string]string)
+
+	for _, value := range envArg {
+		key, value, ok := strings.Cut(value, "=")
+
+		if !ok {
+			return fmt.Errorf("environment variable must be in the form KEY=value: %s", value)
+		}
+
+		env[key] = value
+	}
+
+	startRequest := &job.StartRequest{
+		Command:    &job.Command{Name: *jobCommandArg, Args: strings.Fields(*argsArg)},
+		Env:        env,
+		WorkingDir: *workingDirArg,
+		Priority:   int32(*priorityArg),
+	}
+
+	if *timeoutArg != 0 {
+		startRequest.Timeout = durationpb.New(*timeoutArg)
+	}
+
+	s.request = &job.CreateScheduleRequest{
+		Name:              *nameArg,
+		Cron:              *cronArg,
+		Timezone:          *timezoneArg,
+		ConcurrencyPolicy: job.ConcurrencyPolicy(policy),
+		Job:               startRequest,
+	}
+
+	return nil
+}
+
+func (s *ScheduleCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	var schedules []*job.ScheduleInfo
+
+	switch s.action {
+	case CreateSchedule:
+		resp, err := s.client.CreateSchedule(ctx, s.request)
+
+		if err != nil {
+			fmt.Println(err)
+
+			os.Exit(1)
+		}
+
+		logging.Log.Debug("Create schedule response", "response", resp)
+
+		schedules = append(schedules, resp.Info)
+	case ListSchedules:
+		resp, err := s.client.ListSchedules(ctx, &job.ListSchedulesRequest{})
+
+		if err != nil {
+			fmt.Println(err)
+
+			os.Exit(1)
+		}
+
+		logging.Log.Debug("List schedules response", "response", resp)
+
+		schedules = resp.Schedules
+	case DeleteSchedule:
+		resp, err := s.client.DeleteSchedule(ctx, &job.DeleteScheduleRequest{Id: s.scheduleID})
+
+		if err != nil {
+			fmt.Println(err)
+
+			os.Exit(1)
+		}
+
+		logging.Log.Debug("Delete schedule response", "response", resp)
+
+		fmt.Printf("Deleted schedule: %s\n", resp.Info.ID)
+
+		return
+	}
+
+	printSchedules(schedules)
+
+	// Printed to stderr so that the table can still be parsed
+	if s.action == CreateSchedule {
+		fmt.Fprintln(os.Stderr, "Schedules are not persisted; create the schedule again after the server restarts")
+	}
+}
+
+// printSchedules Print a summary of each schedule as a table; schedules that haven't started a job have no last job.
+func printSchedules(schedules []*job.ScheduleInfo) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ID\tNAME\tCRON\tTIMEZONE\tPOLICY\tNEXT RUN\tLAST JOB\tSKIPPED\tCOMMAND")
+
+	for _, info := range schedules {
+		name, lastJob := "-", "-"
+
+		if info.Name != "" {
+			name = info.Name
+		}
+
+		if len(info.JobIds) > 0 {
+			lastJob = info.JobIds[len(info.JobIds)-1]
+		}
+
+		command := strings.Join(append([]string{info.Command.GetName()}, info.Command.GetArgs()...), " ")
+
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			info.ID, name, info.Cron, info.Timezone, strings.ToLower(info.ConcurrencyPolicy.String()),
+			formatScheduleTime(info.NextRun), lastJob, info.SkippedRuns, command,
+		)
+
+		if info.LastError != "" {
+			fmt.Fprintf(w, "\tlast error: %s\n", info.LastError)
+		}
+	}
+
+	w.Flush()
+}
+
+// formatScheduleTime Returns the time in RFC 3339 format; "-" if it is unset.
+func formatScheduleTime(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.AsTime().Format(time.RFC3339)
+}
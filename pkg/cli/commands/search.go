@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type SearchCmd struct {
+	client job.JobClient
+
+	jobID        string
+	pattern      string
+	contextLines int
+	streams      []job.OutputStream
+	filters      []string
+	printer      jsonPrinter
+	timeout      time.Duration
+}
+
+func (s *SearchCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *SearchCmd) ParseCLI(set *flag.FlagSet) error {
+	idArg := set.String("id", "", "ID of the job whose output is searched")
+	contextArg := set.Int("C", 0, "number of lines of context to print before and after each matching line")
+	streamArg := set.String("stream", "", "search only one stream of output; one of: stdout, stderr")
+	timeoutArg := set.Duration("timeout", 0, "maximum amount of time to search for, e.g. 30s; the search runs until it finishes if unset")
+	printerArgs := parseJSONPrinterArgs(set)
+
+	var filterArg stringsFlag
+
+	set.Var(&filterArg, "filter", "match only lines of JSON output whose field matches in the form field=value, e.g. level=error; can be specified multiple times")
+
+	if err := parseOSArgs(set); err != nil {
+		return err
+	}
+
+	if set.NArg() != 1 {
+		return errors.New("a single pattern must be specified, e.g. grep -id some-job-id 'error|warn'")
+	}
+
+	switch strings.ToLower(*streamArg) {
+	case "":
+	case "stdout":
+		s.streams = []job.OutputStream{job.OutputStream_STDOUT}
+	case "stderr":
+		s.streams = []job.OutputStream{job.OutputStream_STDERR}
+	default:
+		return fmt.Errorf("invalid stream: %s", *streamArg)
+	}
+
+	s.jobID = *idArg
+	s.pattern = set.Arg(0)
+	s.contextLines = *contextArg
+	s.filters = filterArg
+	s.printer = printerArgs()
+	s.timeout = *timeoutArg
+
+	return nil
+}
+
+func (s *SearchCmd) Run() {
+	// Searching a large amount of output can take longer than other requests, so there is no deadline by default
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+
+	if s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+	}
+
+	defer cancel()
+
+	stream, err := s.client.Search(ctx, &job.SearchRequest{
+		Id:           s.jobID,
+		Pattern:      s.pattern,
+		ContextLines: int32(s.contextLines),
+		Streams:      s.streams,
+		Filters:      s.filters,
+	})
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	for {
+		match, err := stream.Recv()
+
+		if err == io.EOF {
+			return
+		}
+
+		if err != nil {
+			logging.Log.Error("Failed to read stream", "err", err)
+
+			os.Exit(1)
+		}
+
+		// Matching lines are separated from their prefix with ":" and context lines with "-", the same as grep
+		separator := ":"
+
+		if match.Context {
+			separator = "-"
+		}
+
+		fmt.Printf(
+			"%s %s%s%d%s%d%s%s\n",
+			match.Time.AsTime().Format(time.RFC3339), strings.ToLower(match.Stream.String()), separator,
+			match.LineNumber, separator, match.Offset, separator, s.printer.format(match.Line),
+		)
+	}
+}
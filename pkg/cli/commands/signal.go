@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"os"
+	"strings"
+)
+
+type SignalCmd struct {
+	client job.JobClient
+
+	jobID  string
+	signal string
+	target job.SignalTarget
+}
+
+func (s *SignalCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *SignalCmd) ParseCLI(set *flag.FlagSet) error {
+	idArg := set.String("id", "", "ID of the job to signal")
+	signalArg := set.String("signal", "TERM", "name or number of the signal to send, e.g. HUP or SIGUSR1")
+	targetArg := set.String("target", "leader", "processes to signal; one of: leader, group, cgroup")
+
+	if err := parseOSArgs(set); err != nil {
+		return err
+	}
+
+	target, ok := job.SignalTarget_value[strings.ToUpper(*targetArg)]
+
+	if !ok {
+		return fmt.Errorf("invalid signal target: %s", *targetArg)
+	}
+
+	s.jobID = *idArg
+	s.signal = *signalArg
+	s.target = job.SignalTarget(target)
+
+	return nil
+}
+
+func (s *SignalCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	resp, err := s.client.Signal(ctx, &job.SignalRequest{Id: s.jobID, Signal: s.signal, Target: s.target})
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	fmt.Println(resp.String())
+
+	logging.Log.Debug("Signal response", "response", resp)
+}
@@ -2,11 +2,18 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type StartCmd struct {
@@ -14,9 +21,23 @@ type StartCmd struct {
 
 	jobCommand  string
 	args        []string
-	memoryLimit uint64
-	cpuLimit    int32
-	diskIOLimit int32
+	memoryLimit *uint64
+	cpuLimit    *int32
+	diskIOLimit *int32
+	timeout     time.Duration
+	deadline    time.Time
+	restart     *job.RestartPolicy
+	env         map[string]string
+	workingDir  string
+	umask       *uint32
+	stdin       []byte
+	interactive bool
+	tty         bool
+	format      job.OutputFormat
+	priority    int32
+	preempt     bool
+	labels      map[string]string
+	isolation   *job.Isolation
 }
 
 func (s *StartCmd) SetClient(client job.JobClient) {
@@ -26,19 +47,167 @@ func (s *StartCmd) SetClient(client job.JobClient) {
 func (s *StartCmd) ParseCLI(set *flag.FlagSet) error {
 	jobCommandArg := set.String("command", "", "job command to run")
 	argsArg := set.String("args", "", "arguments for the job command")
-	memoryArg := set.Uint64("mem-limit", 0, "maximum amount of memory the job command can use in bytes")
-	cpuArg := set.Int("cpu-limit", 0, "maximum percentage of CPU the job command can use")
-	diskIOArg := set.Int("io-limit", 0, "maximum bytes per second the job command can read and write")
+	memoryArg := set.Uint64("mem-limit", 0, "maximum amount of memory the job command can use in bytes; uses the server's default if unset")
+	cpuArg := set.Int("cpu-limit", 0, "maximum percentage of CPU the job command can use; uses the server's default if unset")
+	diskIOArg := set.Int("io-limit", 0, "maximum bytes per second the job command can read and write; uses the server's default if unset")
+	timeoutArg := set.Duration("timeout", 0, "maximum amount of time the job command can run, e.g. 1h30m; uses the server's default if unset")
+	deadlineArg := set.String("deadline", "", "time the job command must finish by in RFC 3339 format, e.g. 2024-06-01T15:04:05Z")
+	restartArg := set.String("restart", "never", "when to restart the job command after it exits; one of: never, on-failure, always")
+	maxRetriesArg := set.Int("max-retries", 0, "maximum number of times to restart the job command; zero means there is no maximum")
+	backoffArg := set.Duration("backoff", 0, "how long to wait before the first restart, doubled after each restart; 1s if unset")
+	maxBackoffArg := set.Duration("max-backoff", 0, "longest amount of time to wait between restarts; 5m if unset")
+	workingDirArg := set.String("workdir", "", "working directory of the job command; uses the server's working directory if unset")
+	umaskArg := set.String("umask", "", "file mode creation mask of the job command in octal, e.g. 022; uses the server's umask if unset")
+	stdinArg := set.String("stdin", "", "file whose contents are written to the stdin of the job command")
+	interactiveArg := set.Bool("i", false, "stream this command's stdin to the stdin of the job command")
+	ttyArg := set.Bool("t", false, "run the job command with a terminal that can be attached to")
+	formatArg := set.String("output-format", "text", "format of the job command's output; one of: text, json")
+	priorityArg := set.Int("priority", 0, "order in which the job is started when queued; higher priorities start first and may be limited by the server")
+	preemptArg := set.Bool("preempt", false, "stop running jobs with a lower priority to make room for the job if the server has no capacity for it; only allowed for some clients")
+	isolateArg := set.String("isolate", "", "comma-separated namespaces to run the job command in; any of: pid, mount, network")
+
+	var envArg stringsFlag
+
+	set.Var(&envArg, "env", "environment variable of the job command in the form KEY=value; can be specified multiple times")
+
+	var labelArg stringsFlag
+
+	set.Var(&labelArg, "label", "label of the job in the form key=value; can be specified multiple times")
 
 	if err := parseOSArgs(set); err != nil {
 		return err
 	}
 
+	if *deadlineArg != "" {
+		deadline, err := time.Parse(time.RFC3339, *deadlineArg)
+
+		if err != nil {
+			return err
+		}
+
+		s.deadline = deadline
+	}
+
 	s.jobCommand = *jobCommandArg
 	s.args = strings.Fields(*argsArg)
-	s.memoryLimit = *memoryArg
-	s.cpuLimit = int32(*cpuArg)
-	s.diskIOLimit = int32(*diskIOArg)
+
+	// Only limits that were given are sent so that the server applies its defaults to the rest
+	set.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "mem-limit":
+			s.memoryLimit = memoryArg
+		case "cpu-limit":
+			cpu := int32(*cpuArg)
+			s.cpuLimit = &cpu
+		case "io-limit":
+			diskIO := int32(*diskIOArg)
+			s.diskIOLimit = &diskIO
+		}
+	})
+
+	s.timeout = *timeoutArg
+	s.workingDir = *workingDirArg
+	s.env = make(map[string]string)
+
+	for _, env := range envArg {
+		key, value, ok := strings.Cut(env, "=")
+
+		if !ok {
+			return fmt.Errorf("environment variable must be in the form KEY=value: %s", env)
+		}
+
+		s.env[key] = value
+	}
+
+	s.labels = make(map[string]string)
+
+	for _, label := range labelArg {
+		key, value, ok := strings.Cut(label, "=")
+
+		if !ok {
+			return fmt.Errorf("label must be in the form key=value: %s", label)
+		}
+
+		s.labels[key] = value
+	}
+
+	if *isolateArg != "" {
+		s.isolation = &job.Isolation{}
+
+		for _, namespace := range strings.Split(*isolateArg, ",") {
+			switch strings.TrimSpace(namespace) {
+			case "pid":
+				s.isolation.Pid = true
+			case "mount":
+				s.isolation.Mount = true
+			case "network":
+				s.isolation.Network = true
+			default:
+				return fmt.Errorf("invalid namespace: %s; options are: pid, mount, network", namespace)
+			}
+		}
+	}
+
+	if *stdinArg != "" && *interactiveArg {
+		return errors.New("-stdin and -i cannot be used together")
+	}
+
+	if *stdinArg != "" {
+		stdin, err := os.ReadFile(*stdinArg)
+
+		if err != nil {
+			return err
+		}
+
+		s.stdin = stdin
+	}
+
+	s.interactive = *interactiveArg
+	s.tty = *ttyArg
+	s.priority = int32(*priorityArg)
+	s.preempt = *preemptArg
+
+	if s.tty && (s.interactive || s.stdin != nil) {
+		return errors.New("-t cannot be used along with -stdin or -i; use the attach command instead")
+	}
+
+	if *umaskArg != "" {
+		umask, err := strconv.ParseUint(*umaskArg, 8, 32)
+
+		if err != nil {
+			return fmt.Errorf("invalid umask: %s", *umaskArg)
+		}
+
+		umask32 := uint32(umask)
+		s.umask = &umask32
+	}
+
+	format, ok := job.OutputFormat_value[strings.ToUpper(*formatArg)]
+
+	if !ok {
+		return fmt.Errorf("invalid output format: %s", *formatArg)
+	}
+
+	s.format = job.OutputFormat(format)
+
+	restartMode, ok := job.RestartMode_value[strings.ReplaceAll(strings.ToUpper(*restartArg), "-", "_")]
+
+	if !ok {
+		return fmt.Errorf("invalid restart policy: %s", *restartArg)
+	}
+
+	s.restart = &job.RestartPolicy{
+		Mode:       job.RestartMode(restartMode),
+		MaxRetries: int32(*maxRetriesArg),
+	}
+
+	if *backoffArg != 0 {
+		s.restart.InitialBackoff = durationpb.New(*backoffArg)
+	}
+
+	if *maxBackoffArg != 0 {
+		s.restart.MaxBackoff = durationpb.New(*maxBackoffArg)
+	}
 
 	return nil
 }
@@ -58,7 +227,38 @@ func (s *StartCmd) Run() {
 		DiskIoBps:     s.diskIOLimit,
 	}
 
-	resp, err := s.client.Start(ctx, &job.StartRequest{Command: cmd, ResourceLimits: resourceLimits})
+	req := &job.StartRequest{
+		Command:        cmd,
+		ResourceLimits: resourceLimits,
+		RestartPolicy:  s.restart,
+		Env:            s.env,
+		WorkingDir:     s.workingDir,
+		Umask:          s.umask,
+		Stdin:          s.stdin,
+		StdinStream:    s.interactive,
+		Tty:            s.tty,
+		OutputFormat:   s.format,
+		Priority:       s.priority,
+		Preempt:        s.preempt,
+		Labels:         s.labels,
+		Isolation:      s.isolation,
+	}
+
+	if s.tty {
+		if size := getWindowSize(int(os.Stdout.Fd())); size != nil {
+			req.WindowSize = &job.WindowSize{Rows: uint32(size.Row), Cols: uint32(size.Col)}
+		}
+	}
+
+	if s.timeout != 0 {
+		req.Timeout = durationpb.New(s.timeout)
+	}
+
+	if !s.deadline.IsZero() {
+		req.Deadline = timestamppb.New(s.deadline)
+	}
+
+	resp, err := s.client.Start(ctx, req)
 
 	if err != nil {
 		fmt.Println(err)
@@ -67,4 +267,54 @@ func (s *StartCmd) Run() {
 	}
 
 	fmt.Println(resp.Info.ID)
+
+	if s.interactive {
+		if err := writeStdin(s.client, resp.Info.ID, os.Stdin); err != nil {
+			logging.Log.Error("Failed to write stdin", "err", err)
+
+			os.Exit(1)
+		}
+	}
+}
+
+// writeStdin Stream everything from the reader to the stdin of the job, closing the job's stdin once the reader is
+// exhausted.
+func writeStdin(client job.JobClient, jobID string, reader io.Reader) error {
+	// Stdin can be streamed for an arbitrary amount of time so there is no timeout
+	stream, err := client.WriteStdin(context.Background())
+
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 32*1024)
+	req := &job.StdinRequest{Id: jobID}
+
+	for {
+		n, err := reader.Read(buf)
+
+		if n > 0 {
+			req.Data = buf[:n]
+
+			if err := stream.Send(req); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Send(&job.StdinRequest{Id: jobID, Close: true}); err != nil {
+		return err
+	}
+
+	_, err = stream.CloseAndRecv()
+
+	return err
 }
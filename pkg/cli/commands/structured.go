@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/jobs"
+	"io"
+	"strings"
+)
+
+// jsonPrinter Formats lines of structured output, indenting them or keeping only some of their fields; lines that aren't
+// JSON objects are left as they are.
+type jsonPrinter struct {
+	pretty bool
+	fields []string
+}
+
+// parseJSONPrinterArgs Add the flags that configure how structured output is printed, returning a function that creates
+// the printer once the flags are parsed.
+func parseJSONPrinterArgs(set *flag.FlagSet) func() jsonPrinter {
+	prettyArg := set.Bool("pretty", false, "indent lines of output that are JSON objects")
+	fieldsArg := set.String("fields", "", "print only these comma-separated fields of lines that are JSON objects, e.g. time,level,msg")
+
+	return func() jsonPrinter {
+		printer := jsonPrinter{pretty: *prettyArg}
+
+		if *fieldsArg != "" {
+			printer.fields = strings.Split(*fieldsArg, ",")
+		}
+
+		return printer
+	}
+}
+
+// enabled Returns true if lines are changed by the printer.
+func (p jsonPrinter) enabled() bool {
+	return p.pretty || len(p.fields) > 0
+}
+
+// format Format a line without its newline.
+func (p jsonPrinter) format(raw []byte) []byte {
+	if !p.enabled() {
+		return raw
+	}
+
+	line := jobs.ParseLine(jobs.JSONFormat, raw)
+
+	if !line.Structured() {
+		return raw
+	}
+
+	formatted, err := p.project(line)
+
+	if err != nil {
+		return raw
+	}
+
+	if p.pretty {
+		var indented bytes.Buffer
+
+		if err := json.Indent(&indented, formatted, "", "  "); err != nil {
+			return raw
+		}
+
+		formatted = indented.Bytes()
+	}
+
+	return formatted
+}
+
+// project Returns the line as a JSON object with only the printer's fields in the order they were given, or every field
+// if the printer has none.
+func (p jsonPrinter) project(line jobs.Line) ([]byte, error) {
+	if len(p.fields) == 0 {
+		return json.Marshal(line.Fields)
+	}
+
+	// The object is built by hand since maps are marshaled with their keys sorted
+	var object bytes.Buffer
+
+	object.WriteByte('{')
+
+	for _, field := range p.fields {
+		value, ok := line.Value(field)
+
+		if !ok {
+			continue
+		}
+
+		key, err := json.Marshal(field)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if object.Len() > 1 {
+			object.WriteByte(',')
+		}
+
+		object.Write(key)
+		object.WriteByte(':')
+		object.Write(data)
+	}
+
+	object.WriteByte('}')
+
+	return object.Bytes(), nil
+}
+
+// lineWriter Writes output to a writer line by line, formatting each line with the printer.
+type lineWriter struct {
+	w       io.Writer
+	printer jsonPrinter
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+
+		if i < 0 {
+			return len(p), nil
+		}
+
+		if _, err := w.w.Write(append(w.printer.format(w.partial[:i]), '\n')); err != nil {
+			return 0, err
+		}
+
+		w.partial = w.partial[i+1:]
+	}
+}
+
+// flush Write the last line if it didn't end with a newline.
+func (w *lineWriter) flush() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+
+	_, err := w.w.Write(w.printer.format(w.partial))
+	w.partial = nil
+
+	return err
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestJSONPrinter_format(t *testing.T) {
+	tests := []struct {
+		name    string
+		printer jsonPrinter
+		line    string
+		want    string
+	}{
+		{
+			name:    "Should keep fields in the order they were given",
+			printer: jsonPrinter{fields: []string{"msg", "level", "http.status"}},
+			line:    `{"level":"info","msg":"done","http":{"status":200},"time":"now"}`,
+			want:    `{"msg":"done","level":"info","http.status":200}`,
+		},
+		{
+			name:    "Should leave out missing fields",
+			printer: jsonPrinter{fields: []string{"msg", "missing"}},
+			line:    `{"msg":"done"}`,
+			want:    `{"msg":"done"}`,
+		},
+		{
+			name:    "Should indent the projected fields",
+			printer: jsonPrinter{pretty: true, fields: []string{"b", "a"}},
+			line:    `{"a":1,"b":2}`,
+			want:    "{\n  \"b\": 2,\n  \"a\": 1\n}",
+		},
+		{
+			name:    "Should leave lines that aren't JSON objects as they are",
+			printer: jsonPrinter{fields: []string{"msg"}},
+			line:    "plain text",
+			want:    "plain text",
+		},
+		{
+			name: "Should leave lines as they are without options",
+			line: `{"b":1,"a":2}`,
+			want: `{"b":1,"a":2}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.printer.format([]byte(tt.line)); string(got) != tt.want {
+				t.Errorf("format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
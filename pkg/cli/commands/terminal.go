@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// isTerminal Returns true if the file descriptor refers to a terminal.
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+
+	return err == nil
+}
+
+// makeRaw Put the terminal into raw mode so that input is passed through unprocessed, e.g. without echoing or line
+// buffering; returns a function that restores the terminal's previous state.
+func makeRaw(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *termios
+
+	// Same settings as cfmakeraw(3)
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, &previous)
+	}, nil
+}
+
+// getWindowSize Get the size of the terminal; nil if the file descriptor doesn't refer to a terminal.
+func getWindowSize(fd int) *unix.Winsize {
+	size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+
+	if err != nil {
+		return nil
+	}
+
+	return size
+}
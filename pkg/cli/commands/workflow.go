@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/api/proto/job"
+	"github.com/kurczynski/teleport-job-worker/internal/logging"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// ApplyWorkflow Submit the workflow described by a YAML file.
+	ApplyWorkflow = "apply"
+	// QueryWorkflow Print the status of a workflow and its steps.
+	QueryWorkflow = "query"
+	// CancelWorkflow Cancel a workflow.
+	CancelWorkflow = "cancel"
+	// DeleteWorkflow Delete a workflow that has ended.
+	DeleteWorkflow = "delete"
+)
+
+type WorkflowCmd struct {
+	client job.JobClient
+
+	action     string
+	workflowID string
+	request    *job.SubmitWorkflowRequest
+}
+
+// workflowSpec Workflow described by a YAML file.
+type workflowSpec struct {
+	Name  string     `yaml:"name"`
+	Steps []stepSpec `yaml:"steps"`
+}
+
+// stepSpec Step of a workflow described by a YAML file.
+type stepSpec struct {
+	Name      string   `yaml:"name"`
+	DependsOn []string `yaml:"depends_on"`
+	jobSpec   `yaml:",inline"`
+}
+
+func (s *WorkflowCmd) SetClient(client job.JobClient) {
+	s.client = client
+}
+
+func (s *WorkflowCmd) ParseCLI(set *flag.FlagSet) error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf(
+			"a workflow action must be specified; options are: %s, %s, %s, %s", ApplyWorkflow, QueryWorkflow,
+			CancelWorkflow, DeleteWorkflow,
+		)
+	}
+
+	s.action = os.Args[2]
+
+	var fileArg string
+
+	switch s.action {
+	case ApplyWorkflow:
+		set.StringVar(&fileArg, "f", "", "YAML file describing the workflow")
+	case QueryWorkflow, CancelWorkflow:
+		set.StringVar(&s.workflowID, "id", "", "ID of the workflow")
+	case DeleteWorkflow:
+		set.StringVar(&s.workflowID, "id", "", "ID of the ended workflow to delete; the jobs of its steps are kept")
+	default:
+		return fmt.Errorf(
+			"invalid workflow action: %s; options are: %s, %s, %s, %s", s.action, ApplyWorkflow, QueryWorkflow,
+			CancelWorkflow, DeleteWorkflow,
+		)
+	}
+
+	if err := set.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+
+	if s.action != ApplyWorkflow {
+		return nil
+	}
+
+	if fileArg == "" {
+		return errors.New("a workflow file must be specified with -f")
+	}
+
+	request, err := readWorkflow(fileArg)
+
+	if err != nil {
+		return err
+	}
+
+	s.request = request
+
+	return nil
+}
+
+func (s *WorkflowCmd) Run() {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxTimeout)
+	defer cancel()
+
+	var resp *job.WorkflowResponse
+	var err error
+
+	switch s.action {
+	case ApplyWorkflow:
+		resp, err = s.client.SubmitWorkflow(ctx, s.request)
+	case QueryWorkflow:
+		resp, err = s.client.QueryWorkflow(ctx, &job.QueryWorkflowRequest{Id: s.workflowID})
+	case CancelWorkflow:
+		resp, err = s.client.CancelWorkflow(ctx, &job.CancelWorkflowRequest{Id: s.workflowID})
+	case DeleteWorkflow:
+		resp, err = s.client.DeleteWorkflow(ctx, &job.DeleteWorkflowRequest{Id: s.workflowID})
+	}
+
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	printWorkflow(resp.Info)
+
+	logging.Log.Debug("Workflow response", "response", resp)
+}
+
+// readWorkflow Read the workflow described by the YAML file as a request to submit it. Unknown fields are errors so
+// that misspelled fields aren't silently left out.
+func readWorkflow(path string) (*job.SubmitWorkflowRequest, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	var spec workflowSpec
+
+	// An empty file describes a workflow without steps, which the server rejects
+	if err := decoder.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid workflow file %s: %w", path, err)
+	}
+
+	req := &job.SubmitWorkflowRequest{Name: spec.Name}
+
+	for _, step := range spec.Steps {
+		req.Steps = append(req.Steps, &job.WorkflowStep{
+			Name:      step.Name,
+			Job:       step.startRequest(),
+			DependsOn: step.DependsOn,
+		})
+	}
+
+	return req, nil
+}
+
+// printWorkflow Print the workflow's status followed by the status of each of its steps as a table; steps that haven't
+// started have no job.
+func printWorkflow(info *job.WorkflowInfo) {
+	fmt.Printf("Workflow: %s\n", info.ID)
+
+	if info.Name != "" {
+		fmt.Printf("Name: %s\n", info.Name)
+	}
+
+	fmt.Printf("Status: %s\n", strings.TrimPrefix(info.Status.String(), "WORKFLOW_"))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "STEP\tSTATUS\tJOB\tDEPENDS ON\tINFO")
+
+	for _, step := range info.Steps {
+		jobID := "-"
+
+		if step.JobId != "" {
+			jobID = step.JobId
+		}
+
+		dependsOn := "-"
+
+		if len(step.DependsOn) > 0 {
+			dependsOn = strings.Join(step.DependsOn, ",")
+		}
+
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\n",
+			step.Name, strings.TrimPrefix(step.Status.String(), "STEP_"), jobID, dependsOn, step.StatusInfo,
+		)
+	}
+
+	w.Flush()
+}
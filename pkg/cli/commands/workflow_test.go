@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadWorkflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		workflow  string
+		wantSteps int
+		wantErr   bool
+	}{
+		{
+			name: "Should read the steps",
+			workflow: "name: build\nsteps:\n" +
+				"  - name: compile\n    command: make\n" +
+				"  - name: test\n    command: make\n    depends_on: [compile]\n",
+			wantSteps: 2,
+		},
+		{
+			name:     "Should reject unknown fields of steps",
+			workflow: "steps:\n  - name: compile\n    command: make\n    dependson: [test]\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Should reject unknown fields of the workflow",
+			workflow: "nmae: build\n",
+			wantErr:  true,
+		},
+		{
+			name: "Should read an empty workflow",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workflow.yaml")
+
+			if err := os.WriteFile(path, []byte(tt.workflow), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := readWorkflow(path)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readWorkflow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := len(req.GetSteps()); got != tt.wantSteps {
+				t.Errorf("readWorkflow() steps = %d, want %d", got, tt.wantSteps)
+			}
+		})
+	}
+}
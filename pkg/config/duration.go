@@ -0,0 +1,29 @@
+package config
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Duration Wrapper to allow durations to be written as strings in the config file, e.g. "1h30m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var value string
+
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		return err
+	}
+
+	d.Duration = duration
+
+	return nil
+}
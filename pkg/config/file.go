@@ -8,11 +8,29 @@ import (
 )
 
 type ServerConfig struct {
-	Certs      Certs      `json:"certs"`
-	Host       string     `json:"host"`
-	LogLevel   slog.Level `json:"logLevel"`
-	Port       int        `json:"port"`
-	WorkerName string     `json:"workerName"`
+	Certs Certs `json:"certs"`
+	// ExecIdentities Identities (certificate common names) of clients allowed to execute commands inside of jobs.
+	ExecIdentities []string   `json:"execIdentities"`
+	Host           string     `json:"host"`
+	InheritEnv     []string   `json:"inheritEnv"`
+	LogLevel       slog.Level `json:"logLevel"`
+	// MaxPriority Highest priority that clients without a priority identity can start jobs with; zero only allows the
+	// default and lower priorities.
+	MaxPriority int32  `json:"maxPriority"`
+	Output      Output `json:"output"`
+	Port        int    `json:"port"`
+	// PriorityIdentities Identities (certificate common names) of clients allowed to start jobs with any priority and to
+	// preempt running jobs.
+	PriorityIdentities []string `json:"priorityIdentities"`
+	// Quotas Limits on the jobs of each client keyed by identity (certificate common name); "*" applies to every client
+	// without a quota of its own.
+	Quotas map[string]Quota `json:"quotas"`
+	// ResourceLimits Limits applied to jobs started without them and the largest limits jobs can be started with.
+	ResourceLimits ResourceLimits `json:"resourceLimits"`
+	Scheduler      Scheduler      `json:"scheduler"`
+	Store          Store          `json:"store"`
+	Timeout        Timeout        `json:"timeout"`
+	WorkerName     string         `json:"workerName"`
 }
 
 type Certs struct {
@@ -21,6 +39,102 @@ type Certs struct {
 	KeyFile  string `json:"keyFile"`
 }
 
+// Scheduler Capacity of the server; jobs started beyond it are queued until running jobs end. A zero value means there
+// is no limit.
+type Scheduler struct {
+	// MaxRunning Maximum number of jobs that run at the same time.
+	MaxRunning int `json:"maxRunning"`
+	// MaxCPUPercentage Maximum sum of the CPU percentages of the jobs that run at the same time.
+	MaxCPUPercentage int64 `json:"maxCPUPercentage"`
+	// MaxMemoryBytes Maximum sum of the memory limits of the jobs that run at the same time.
+	MaxMemoryBytes uint64 `json:"maxMemoryBytes"`
+}
+
+// ResourceLimits Resource limits of jobs enforced by the server.
+type ResourceLimits struct {
+	// Default Limits applied to jobs that are started without them; a zero value means the resource is unlimited, or
+	// limited to the maximum if there is one.
+	Default Resources `json:"default"`
+	// Max Largest limits a job can be started with; a zero value means there is no maximum.
+	Max Resources `json:"max"`
+}
+
+// Resources Limits on the resources a single job can use.
+type Resources struct {
+	// MemoryBytes Amount of memory in bytes that a job can use.
+	MemoryBytes uint64 `json:"memoryBytes"`
+	// CPUPercentage Percentage of CPU that a job can use.
+	CPUPercentage int32 `json:"cpuPercentage"`
+	// DiskIOBPS Bytes per second a job can read from and write to a disk.
+	DiskIOBPS int32 `json:"diskIOBPS"`
+}
+
+// Quota Limits on the jobs of a single client; a zero value means there is no limit. Active jobs are jobs that haven't
+// ended, including queued jobs.
+type Quota struct {
+	// MaxJobs Maximum number of active jobs.
+	MaxJobs int `json:"maxJobs"`
+	// MaxCPUPercentage Maximum sum of the CPU percentages of active jobs.
+	MaxCPUPercentage int64 `json:"maxCPUPercentage"`
+	// MaxMemoryBytes Maximum sum of the memory limits of active jobs.
+	MaxMemoryBytes uint64 `json:"maxMemoryBytes"`
+	// MaxJobCPUPercentage Maximum CPU percentage of a single job.
+	MaxJobCPUPercentage int64 `json:"maxJobCPUPercentage"`
+	// MaxJobMemoryBytes Maximum memory limit of a single job.
+	MaxJobMemoryBytes uint64 `json:"maxJobMemoryBytes"`
+	// MaxOutputBytes Maximum disk space used by the output of all of the client's jobs.
+	MaxOutputBytes int64 `json:"maxOutputBytes"`
+}
+
+// Store Settings for persisting the metadata of jobs across restarts of the server.
+type Store struct {
+	// Path Absolute path of the file that the metadata of jobs is stored in. The metadata includes the environment
+	// variables of jobs, so the file and its directory are created readable only by the server's user.
+	Path string `json:"path"`
+	// Disabled Keep the metadata of jobs only in memory so that it is lost when the server restarts.
+	Disabled bool `json:"disabled"`
+	// Orphans What is done with the processes of jobs that were running when the server last exited, either "reap" to
+	// kill them or "reattach" to keep managing them; they are reaped if empty.
+	Orphans string `json:"orphans"`
+}
+
+// Timeout Limits on how long jobs are allowed to run; a zero value means there is no limit.
+type Timeout struct {
+	// Default Timeout applied to jobs that are started without one.
+	Default Duration `json:"default"`
+	// Max Largest timeout a job can be started with.
+	Max Duration `json:"max"`
+}
+
+// Output Settings for storing the output of jobs.
+type Output struct {
+	// Dir Directory that the output of every job is stored in; each job gets its own directory inside of it.
+	Dir string `json:"dir"`
+	// TTL How long the output of a job is kept after the job ends; zero means output is kept until it is evicted.
+	TTL Duration `json:"ttl"`
+	// MaxTotalBytes Maximum disk space used by the output of all jobs before the least recently used output of
+	// finished jobs is evicted; zero means there is no maximum.
+	MaxTotalBytes int64 `json:"maxTotalBytes"`
+	// MaxJobBytes Maximum amount of output a single job can write before it is truncated; zero means there is no
+	// maximum.
+	MaxJobBytes int64 `json:"maxJobBytes"`
+	// SweepInterval How often the retention policy is applied.
+	SweepInterval Duration `json:"sweepInterval"`
+	// Rotation When the output of a job is rotated into new segments.
+	Rotation Rotation `json:"rotation"`
+}
+
+// Rotation Settings for rotating the output of long-running jobs into segments; output isn't rotated by default.
+type Rotation struct {
+	// MaxSegmentBytes Size at which a segment is closed and a new one is started; zero means there is no maximum.
+	MaxSegmentBytes int64 `json:"maxSegmentBytes"`
+	// MaxSegmentAge How long output is written to a segment before a new one is started; zero means there is no
+	// maximum.
+	MaxSegmentAge Duration `json:"maxSegmentAge"`
+	// Compression Compression of closed segments, either "gzip" or "zstd"; segments aren't compressed if empty.
+	Compression string `json:"compression"`
+}
+
 func LoadServerConfig(fname string) *ServerConfig {
 	configFile, err := os.ReadFile(fname)
 
@@ -30,7 +144,9 @@ func LoadServerConfig(fname string) *ServerConfig {
 
 	config := &ServerConfig{}
 
-	err = json.Unmarshal(configFile, config)
+	if err = json.Unmarshal(configFile, config); err != nil {
+		log.Fatal(err)
+	}
 
 	return config
 }
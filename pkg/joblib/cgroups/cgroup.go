@@ -2,15 +2,24 @@ package cgroups
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	// DefaultRoot Path that the cgroup v2 hierarchy is mounted at.
+	DefaultRoot = "/sys/fs/cgroup"
+
+	// unlimited Value of a cgroup interface file that removes a limit.
+	unlimited = "max"
+)
+
 var (
 	// TODO: This should be loaded or injected, not hardcoded
 	logger = slog.New(slog.NewTextHandler(
@@ -24,13 +33,17 @@ var (
 
 // Cgroup Information used to construct cgroup.
 type Cgroup struct {
-	fd         int
+	// file Keeps the cgroup directory open; the descriptor would be closed if the file was garbage collected.
+	file *os.File
+	fd   int
+	// fileMu Guards file and fd; a job that is stopped while it exits cleans up its cgroup twice.
+	fileMu     sync.Mutex
 	jobID      string
 	root       string
 	workerName string
 }
 
-// Resources cgroup limits that can be configured for jobs.
+// Resources cgroup limits that can be configured for jobs; a limit of zero means the resource is unlimited.
 type Resources struct {
 	CPUPercentage int32
 	DiskIOBPS     int32
@@ -60,24 +73,77 @@ func NewCgroup(cgroupRoot string, workerName string, jobID string) (*Cgroup, err
 	if err := os.MkdirAll(jobPath, 0644); err != nil {
 		logger.Error("Failed to create cgroup", "path", jobPath)
 
+		return nil, &Error{Op: "create", Path: jobPath, Err: err}
+	}
+
+	if err := cg.open(); err != nil {
 		return nil, err
 	}
 
-	if f, err := os.Open(jobPath); err != nil {
+	return cg, nil
+}
+
+// OpenCgroup Opens the existing cgroup of the given job, e.g. one left behind by a previous process.
+func OpenCgroup(cgroupRoot string, workerName string, jobID string) (*Cgroup, error) {
+	cg := &Cgroup{
+		root:       cgroupRoot,
+		workerName: workerName,
+		jobID:      jobID,
+	}
+
+	if err := cg.open(); err != nil {
 		return nil, err
-	} else {
-		cg.fd = int(f.Fd())
 	}
 
 	return cg, nil
 }
 
-// FD Returns the cgroup file descriptor.
+// JobIDs Returns the IDs of every job that has a cgroup in the worker's cgroup.
+func JobIDs(cgroupRoot string, workerName string) ([]string, error) {
+	entries, err := os.ReadDir(strings.Join([]string{cgroupRoot, workerName}, string(os.PathSeparator)))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+
+	return ids, nil
+}
+
+// open Open the job's cgroup directory so that processes can be started in it.
+func (c *Cgroup) open() error {
+	f, err := os.Open(c.withJobPath())
+
+	if errors.Is(err, os.ErrNotExist) {
+		return &Error{Op: "open", Path: c.withJobPath(), Err: fmt.Errorf("%w: %w", ErrCgroupNotFound, err)}
+	} else if err != nil {
+		return &Error{Op: "open", Path: c.withJobPath(), Err: err}
+	}
+
+	c.file = f
+	c.fd = int(f.Fd())
+
+	return nil
+}
+
+// FD Returns the cgroup file descriptor, or -1 once the cgroup has been cleaned up.
 func (c *Cgroup) FD() int {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
 	return c.fd
 }
 
-// Configure Configure a cgroup with the given resource limits.
+// Configure Configure a cgroup with the given resource limits; a limit of zero leaves the resource unlimited.
 func (c *Cgroup) Configure(resourceLimits Resources) error {
 	if err := c.setSubtreeController("+memory +cpu +io"); err != nil {
 		return err
@@ -109,6 +175,8 @@ func (c *Cgroup) Configure(resourceLimits Resources) error {
 
 // Cleanup Remove cgroup files created for the job.
 func (c *Cgroup) Cleanup() {
+	c.closeFile()
+
 	if err := os.RemoveAll(c.withJobPath()); err != nil {
 		logger.Error("Failed to cleanup cgroup", "err", err)
 
@@ -118,39 +186,134 @@ func (c *Cgroup) Cleanup() {
 	logger.Debug("Cleaned up cgroup", "path", c.withJobPath())
 }
 
-// setMemory Set the maximum amount of memory the job can use in bytes.
+// closeFile Close the cgroup directory once it is no longer needed to start the job.
+func (c *Cgroup) closeFile() {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	if c.file == nil {
+		return
+	}
+
+	if err := c.file.Close(); err != nil {
+		logger.Warn("Failed to close cgroup directory", "err", err)
+	}
+
+	c.file = nil
+	c.fd = -1
+}
+
+// Procs Returns the PIDs of every process that is currently a member of the job's cgroup.
+func (c *Cgroup) Procs() ([]int, error) {
+	f, err := os.Open(c.withJobPath("cgroup.procs"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var pids []int
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		pid, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		pids = append(pids, pid)
+	}
+
+	return pids, scanner.Err()
+}
+
+// Populated Returns true if the job's cgroup contains any processes.
+func (c *Cgroup) Populated() (bool, error) {
+	f, err := os.Open(c.withJobPath("cgroup.events"))
+
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), " "); ok && key == "populated" {
+			return value == "1", nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	return false, fmt.Errorf("cgroup events do not say whether the cgroup is populated: %s", f.Name())
+}
+
+// Kill Kill every process that is a member of the job's cgroup.
+func (c *Cgroup) Kill() error {
+	f, err := os.OpenFile(c.withJobPath("cgroup.kill"), os.O_WRONLY, 0644)
+
+	if err != nil {
+		return &Error{Op: "open", Path: c.withJobPath("cgroup.kill"), Err: err}
+	}
+
+	defer f.Close()
+
+	return c.setResource(f, "1")
+}
+
+// setMemory Set the maximum amount of memory the job can use in bytes; zero means there is no maximum.
 func (c *Cgroup) setMemory(memoryMax uint64) error {
 	if f, err := os.OpenFile(c.withJobPath("memory.max"), os.O_WRONLY, 0644); err != nil {
-		return err
+		return interfaceError("open", c.withJobPath("memory.max"), err)
 	} else {
 		defer f.Close()
 
-		value := strconv.FormatUint(memoryMax, 10)
+		value := unlimited
+
+		if memoryMax > 0 {
+			value = strconv.FormatUint(memoryMax, 10)
+		}
+
 		logger.Debug("Setting memory limit", "path", f.Name(), "value", value)
 
 		return c.setResource(f, value)
 	}
 }
 
-// setCPU Set the maximum amount of CPU the job can use as a percentage.
+// setCPU Set the maximum amount of CPU the job can use as a percentage; zero means there is no maximum.
 func (c *Cgroup) setCPU(percentage int32) error {
 	period := 1 * time.Second
-	quota := period.Microseconds() * int64(percentage)
+	quota := unlimited
+
+	if percentage > 0 {
+		quota = strconv.FormatInt(period.Microseconds()*int64(percentage)/100, 10)
+	}
 
 	if f, err := os.OpenFile(c.withJobPath("cpu.max"), os.O_WRONLY, 0644); err != nil {
-		return err
+		return interfaceError("open", c.withJobPath("cpu.max"), err)
 	} else {
 		defer f.Close()
 
-		value := fmt.Sprintf("%d %d\n", quota, period.Microseconds())
+		value := fmt.Sprintf("%s %d\n", quota, period.Microseconds())
 		logger.Debug("Setting CPU limit", "path", f.Name(), "value", value)
 
 		return c.setResource(f, value)
 	}
 }
 
-// setDiskIO Set the maximum amount of disk IO the job can use on a given partition.
+// setDiskIO Set the maximum amount of disk IO the job can use on a given partition; zero means there is no maximum.
 func (c *Cgroup) setDiskIO(bytesSec int32, part partition) error {
+	if bytesSec <= 0 {
+		return nil
+	}
+
 	if part.minor != "0" {
 		logger.Warn("Refusing to set IO limit, IO limits can only be set for physical devices", "partition", part)
 
@@ -158,7 +321,7 @@ func (c *Cgroup) setDiskIO(bytesSec int32, part partition) error {
 	}
 
 	if f, err := os.OpenFile(c.withJobPath("io.max"), os.O_WRONLY, 0644); err != nil {
-		return err
+		return interfaceError("open", c.withJobPath("io.max"), err)
 	} else {
 		defer f.Close()
 
@@ -204,9 +367,9 @@ func getPartitions() ([]partition, error) {
 }
 
 // setResource Generalized function to help set resources in a unified way.
-func (c *Cgroup) setResource(resource io.Writer, value string) error {
+func (c *Cgroup) setResource(resource *os.File, value string) error {
 	if _, err := resource.Write([]byte(value)); err != nil {
-		return err
+		return &Error{Op: "write", Path: resource.Name(), Err: err}
 	}
 
 	return nil
@@ -220,14 +383,15 @@ func (c *Cgroup) setSubtreeController(args ...string) error {
 	f, err := os.OpenFile(c.withWorkerPath("cgroup.subtree_control"), os.O_WRONLY, 0644)
 
 	if err != nil {
-		return err
+		return &Error{Op: "open", Path: c.withWorkerPath("cgroup.subtree_control"), Err: err}
 	}
 
 	defer f.Close()
 
+	// Enabling a controller fails if the parent cgroup doesn't make it available to the worker's cgroup
 	for _, arg := range args {
-		if err := c.setResource(f, arg); err != nil {
-			return err
+		if _, err := f.Write([]byte(arg)); err != nil {
+			return &Error{Op: "enable " + arg, Path: f.Name(), Err: fmt.Errorf("%w: %w", ErrControllerUnavailable, err)}
 		}
 	}
 
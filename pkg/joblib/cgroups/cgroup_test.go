@@ -1,6 +1,10 @@
 package cgroups
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -101,3 +105,167 @@ func TestCgroup_withWorkerPath(t *testing.T) {
 		})
 	}
 }
+
+func TestJobIDs(t *testing.T) {
+	root := t.TempDir()
+
+	for _, id := range []string{"job-one", "job-two"} {
+		if err := os.MkdirAll(filepath.Join(root, "some-worker", id), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Interface files of the worker's cgroup are not jobs
+	if err := os.WriteFile(filepath.Join(root, "some-worker", "cgroup.procs"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := JobIDs(root, "some-worker")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"job-one", "job-two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("JobIDs() = %v, want %v", got, want)
+	}
+
+	if got, err := JobIDs(root, "missing-worker"); err != nil || got != nil {
+		t.Errorf("JobIDs() = %v, %v, want no jobs", got, err)
+	}
+}
+
+func TestCgroup_Populated(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "Should be populated",
+			events: "populated 1\nfrozen 0\n",
+			want:   true,
+		},
+		{
+			name:   "Should not be populated",
+			events: "populated 0\nfrozen 0\n",
+			want:   false,
+		},
+		{
+			name:    "Should fail without populated event",
+			events:  "frozen 0\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "some-worker", "some-job-id")
+
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join(dir, "cgroup.events"), []byte(tt.events), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := OpenCgroup(root, "some-worker", "some-job-id")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.Populated()
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Populated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Populated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCgroup_setLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		resources  Resources
+		wantMemory string
+		wantCPU    string
+	}{
+		{
+			name:       "Should set limits",
+			resources:  Resources{MemoryBytes: 1024, CPUPercentage: 50},
+			wantMemory: "1024",
+			wantCPU:    "500000 1000000\n",
+		},
+		{
+			name:       "Should allow more than one CPU",
+			resources:  Resources{MemoryBytes: 1024, CPUPercentage: 200},
+			wantMemory: "1024",
+			wantCPU:    "2000000 1000000\n",
+		},
+		{
+			name:       "Should remove limits of zero",
+			resources:  Resources{},
+			wantMemory: "max",
+			wantCPU:    "max 1000000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "some-worker", "some-job-id")
+
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"memory.max", "cpu.max"} {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c := &Cgroup{root: root, workerName: "some-worker", jobID: "some-job-id"}
+
+			if err := c.setMemory(tt.resources.MemoryBytes); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.setCPU(tt.resources.CPUPercentage); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, _ := os.ReadFile(filepath.Join(dir, "memory.max")); string(got) != tt.wantMemory {
+				t.Errorf("memory.max = %q, want %q", got, tt.wantMemory)
+			}
+
+			if got, _ := os.ReadFile(filepath.Join(dir, "cpu.max")); string(got) != tt.wantCPU {
+				t.Errorf("cpu.max = %q, want %q", got, tt.wantCPU)
+			}
+		})
+	}
+}
+
+func TestCgroup_errors(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "some-worker", "some-job-id"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenCgroup(root, "some-worker", "other-job-id"); !errors.Is(err, ErrCgroupNotFound) {
+		t.Errorf("OpenCgroup() error = %v, want %v", err, ErrCgroupNotFound)
+	}
+
+	c := &Cgroup{root: root, workerName: "some-worker", jobID: "some-job-id"}
+
+	if err := c.setMemory(1024); !errors.Is(err, ErrControllerUnavailable) {
+		t.Errorf("setMemory() error = %v, want %v", err, ErrControllerUnavailable)
+	}
+}
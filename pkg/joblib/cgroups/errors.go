@@ -0,0 +1,43 @@
+package cgroups
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrControllerUnavailable Returned when a controller needed to limit a resource, e.g. memory, isn't enabled for
+	// the worker's cgroup.
+	ErrControllerUnavailable = errors.New("cgroup controller is unavailable")
+	// ErrCgroupNotFound Returned when opening the cgroup of a job that doesn't have one.
+	ErrCgroupNotFound = errors.New("cgroup does not exist")
+)
+
+// Error Failure of an operation on a cgroup directory or interface file; wraps the cause, which may be one of the
+// sentinel errors of this package.
+type Error struct {
+	// Op Operation that failed, e.g. "create".
+	Op string
+	// Path Cgroup directory or interface file that the operation was applied to.
+	Path string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cgroup %s %s: %s", e.Op, e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// interfaceError Returns the failure to use an interface file; a missing interface file means its controller isn't
+// enabled.
+func interfaceError(op string, path string, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		err = fmt.Errorf("%w: %w", ErrControllerUnavailable, err)
+	}
+
+	return &Error{Op: op, Path: path, Err: err}
+}
@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears How many years ahead the next time matching a cron expression is searched for; expressions that never
+// match, e.g. "0 0 30 2 *", have no next time.
+const cronSearchYears = 5
+
+// cronMacros Expressions that can be used in place of the five fields.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField Range of values a field of a cron expression can have along with the names that can be used for them.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{
+		name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"},
+	}
+	// Sunday is both 0 and 7
+	dowField = cronField{
+		name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"},
+	}
+)
+
+// CronExpression Parsed cron expression with the five standard fields: minute, hour, day of month, month and day of
+// week. Each field is a wildcard, a value, a range or a list of them, optionally with a step, e.g. "*/15 9-17 * * 1-5".
+// As with cron, a time matches if its day matches either the day of month or day of week when both are restricted.
+type CronExpression struct {
+	text   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Whether the day fields are wildcards, which changes how days are matched
+	anyDOM bool
+	anyDOW bool
+}
+
+// ParseCron Parse a cron expression with five fields or one of the macros, e.g. "@daily".
+func ParseCron(text string) (CronExpression, error) {
+	expr := CronExpression{text: text}
+	fields := strings.Fields(text)
+
+	if len(fields) == 1 {
+		macro, ok := cronMacros[strings.ToLower(fields[0])]
+
+		if !ok {
+			return expr, fmt.Errorf("unknown cron macro: %s", fields[0])
+		}
+
+		fields = strings.Fields(macro)
+	}
+
+	if len(fields) != 5 {
+		return expr, fmt.Errorf("cron expression must have 5 fields: %q", text)
+	}
+
+	var err error
+
+	if expr.minute, err = minuteField.parse(fields[0]); err != nil {
+		return expr, err
+	}
+
+	if expr.hour, err = hourField.parse(fields[1]); err != nil {
+		return expr, err
+	}
+
+	if expr.dom, err = domField.parse(fields[2]); err != nil {
+		return expr, err
+	}
+
+	if expr.month, err = monthField.parse(fields[3]); err != nil {
+		return expr, err
+	}
+
+	if expr.dow, err = dowField.parse(fields[4]); err != nil {
+		return expr, err
+	}
+
+	// Sunday can be given as 7, but it is matched as 0
+	if expr.dow&(1<<7) != 0 {
+		expr.dow |= 1
+	}
+
+	expr.anyDOM = fields[2] == "*" || fields[2] == "?"
+	expr.anyDOW = fields[4] == "*" || fields[4] == "?"
+
+	return expr, nil
+}
+
+// String Returns the expression as it was given.
+func (e CronExpression) String() string {
+	return e.text
+}
+
+// Next Returns the first time after the given time that matches the expression in the location, truncated to the
+// minute; the zero time if no time within the next few years matches. A wall-clock time that repeats when clocks are
+// set back only matches the first time unless the expression matches every hour, as with cron.
+func (e CronExpression) Next(after time.Time, location *time.Location) time.Time {
+	t := after.In(location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	everyHour := e.hour == 1<<(hourField.max+1)-1
+
+	for t.Before(limit) {
+		switch {
+		case !has(e.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !e.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case !has(e.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+		case !has(e.minute, t.Minute()), !everyHour && isRepeated(t):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// isRepeated Returns true if the time's wall-clock time already occurred earlier, i.e. it is in the period that is
+// repeated after clocks were set back.
+func isRepeated(t time.Time) bool {
+	_, offset := t.Zone()
+
+	// Clocks are set back by at most a few hours, so a larger offset before then is the offset they were set back from
+	_, before := t.Add(-3 * time.Hour).Zone()
+
+	if before <= offset {
+		return false
+	}
+
+	earlier := t.Add(-time.Duration(before-offset) * time.Second)
+
+	return earlier.Day() == t.Day() && earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute()
+}
+
+// matchesDay Returns true if the time's day matches the expression's day of month and day of week.
+func (e CronExpression) matchesDay(t time.Time) bool {
+	dom, dow := has(e.dom, t.Day()), has(e.dow, int(t.Weekday()))
+
+	if e.anyDOM || e.anyDOW {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// has Returns true if the value is in the set of values of a field.
+func has(set uint64, value int) bool {
+	return set&(1<<value) != 0
+}
+
+// parse Parse a field of a cron expression as the set of values it matches.
+func (f cronField) parse(text string) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(text, ",") {
+		values, step, hasStep := strings.Cut(part, "/")
+		low, high := f.min, f.max
+
+		switch {
+		case values == "*" || values == "?":
+		case strings.Contains(values, "-"):
+			from, to, _ := strings.Cut(values, "-")
+
+			var err error
+
+			if low, err = f.value(from); err != nil {
+				return 0, err
+			}
+
+			if high, err = f.value(to); err != nil {
+				return 0, err
+			}
+
+			if low > high {
+				return 0, fmt.Errorf("invalid %s range: %s", f.name, values)
+			}
+		default:
+			value, err := f.value(values)
+
+			if err != nil {
+				return 0, err
+			}
+
+			low = value
+
+			// A single value with a step starts at the value and runs to the end of the range, e.g. "5/15"
+			if !hasStep {
+				high = value
+			}
+		}
+
+		increment := 1
+
+		if hasStep {
+			n, err := strconv.Atoi(step)
+
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid %s step: %s", f.name, step)
+			}
+
+			increment = n
+		}
+
+		for value := low; value <= high; value += increment {
+			set |= 1 << value
+		}
+	}
+
+	return set, nil
+}
+
+// value Parse a single value of the field, which is either a number or a name.
+func (f cronField) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return i + f.min, nil
+		}
+	}
+
+	value, err := strconv.Atoi(text)
+
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %s: %s", f.name, text)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrJobNotRunning Returned when an operation requires a running job, e.g. signaling it.
+	ErrJobNotRunning = errors.New("job is not running")
+	// ErrJobEnded Returned when an operation cannot be applied to a job that has ended, e.g. stopping it.
+	ErrJobEnded = errors.New("job has ended")
+	// ErrJobNotEnded Returned when an operation requires a job that has ended, e.g. deleting its output.
+	ErrJobNotEnded = errors.New("job has not ended")
+	// ErrInvalidTransition Returned when a job cannot change from its status to the requested one, e.g. starting a job
+	// that has already been started.
+	ErrInvalidTransition = errors.New("invalid job status transition")
+	// ErrExecutableNotFound Returned when the job's command cannot be found or isn't executable.
+	ErrExecutableNotFound = errors.New("executable not found")
+	// ErrInvalidOptions Returned when a job is created with options that cannot be used to run it.
+	ErrInvalidOptions = errors.New("invalid job options")
+	// ErrNoTerminal Returned when attaching to a job that wasn't started with a terminal.
+	ErrNoTerminal = errors.New("job does not have a terminal")
+	// ErrJobIsolated Returned when an operation cannot be applied to a job whose command is isolated in namespaces, e.g.
+	// executing a command inside of it.
+	ErrJobIsolated = errors.New("job is isolated in namespaces")
+	// ErrNoStdinStream Returned when streaming stdin to a job that wasn't started with streamed stdin or whose stdin
+	// has been closed.
+	ErrNoStdinStream = errors.New("job does not accept streamed stdin")
+	// ErrInvalidWorkflow Returned when a workflow is created with steps that cannot be run, e.g. steps whose
+	// dependencies form a cycle.
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+	// ErrWorkflowEnded Returned when an operation cannot be applied to a workflow that has ended, e.g. canceling it.
+	ErrWorkflowEnded = errors.New("workflow has ended")
+	// ErrWorkflowNotEnded Returned when an operation requires a workflow that has ended, e.g. deleting it.
+	ErrWorkflowNotEnded = errors.New("workflow has not ended")
+)
+
+// StatusError Returned when an operation cannot be applied to a job because of its status; wraps a sentinel error
+// describing why, e.g. ErrJobNotRunning.
+type StatusError struct {
+	// ID ID of the job.
+	ID string
+	// Op Operation that was attempted, e.g. "stop".
+	Op     string
+	Status Status
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("cannot %s job with status %s: %s", e.Op, e.Status, e.Err)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
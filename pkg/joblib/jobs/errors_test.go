@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+)
+
+func TestJob_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status Status
+		op     func(j *Job) error
+		want   error
+	}{
+		{
+			name:   "Should not start a job that is running",
+			status: RunningStatus,
+			op:     func(j *Job) error { return j.Start() },
+			want:   ErrInvalidTransition,
+		},
+		{
+			name:   "Should not stop a job that has ended",
+			status: SucceededStatus,
+			op:     func(j *Job) error { return j.Stop() },
+			want:   ErrJobEnded,
+		},
+		{
+			name:   "Should not signal a job that isn't running",
+			status: QueuedStatus,
+			op:     func(j *Job) error { return j.Signal(syscall.SIGTERM, LeaderTarget) },
+			want:   ErrJobNotRunning,
+		},
+		{
+			name:   "Should not delete the output of a job that hasn't ended",
+			status: RunningStatus,
+			op:     func(j *Job) error { return j.deleteOutput() },
+			want:   ErrJobNotEnded,
+		},
+		{
+			name:   "Should not attach to a job without a terminal",
+			status: RunningStatus,
+			op: func(j *Job) error {
+				_, err := j.Attach()
+
+				return err
+			},
+			want: ErrNoTerminal,
+		},
+		{
+			name:   "Should not write stdin to a job without streamed stdin",
+			status: RunningStatus,
+			op:     func(j *Job) error { return j.WriteStdin([]byte("some input")) },
+			want:   ErrNoStdinStream,
+		},
+		{
+			name:   "Should not execute a command in a job isolated in namespaces",
+			status: RunningStatus,
+			op: func(j *Job) error {
+				j.isolation = Isolation{Mount: true}
+				_, err := j.Exec(context.Background(), io.Discard, io.Discard, "true")
+
+				return err
+			},
+			want: ErrJobIsolated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &Job{id: "some-id", status: tt.status, clock: &testClock{time: UnixEpoch()}}
+
+			err := tt.op(j)
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+
+			var statusErr *StatusError
+
+			if errors.As(err, &statusErr) && (statusErr.ID != j.id || statusErr.Status != tt.status) {
+				t.Errorf("StatusError = %+v, want ID %s and status %s", statusErr, j.id, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// Exec Run an additional command inside the sandbox of a running job and wait for it to finish, returning its exit
+// code. The command joins the job's cgroup so it is subject to the same resource limits, and it uses the job's
+// environment and working directory. Commands cannot be executed in jobs isolated in namespaces since they would run
+// outside of them. The command is killed if the context is cancelled.
+func (j *Job) Exec(ctx context.Context, stdout io.Writer, stderr io.Writer, command string, args ...string) (int, error) {
+	logger.Info("Executing command in job", "id", j.id, "command", command, "args", args)
+
+	if j.isolation.enabled() {
+		return -1, fmt.Errorf("%w: cannot execute command in job %s", ErrJobIsolated, j.id)
+	}
+
+	// The cgroup is read along with the status since it is cleaned up once the job ends
+	j.mu.Lock()
+	status := j.status
+	cgroupFD := j.cgroup.FD()
+	j.mu.Unlock()
+
+	if status != RunningStatus || cgroupFD < 0 {
+		return -1, &StatusError{ID: j.id, Op: "execute command in", Status: status, Err: ErrJobNotRunning}
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Env = j.env
+	cmd.Dir = j.dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Processes the command started in the background can keep its stdout and stderr open after it exits
+	cmd.WaitDelay = outputWaitDelay
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CgroupFD:    cgroupFD,
+		Pdeathsig:   syscall.SIGKILL,
+		Setpgid:     true,
+		UseCgroupFD: true,
+	}
+
+	j.setUmask(cmd)
+
+	type result struct {
+		exitCode int
+		err      error
+	}
+
+	done := make(chan result)
+
+	// The parent death signal is sent when the thread that started the command exits, so the thread is locked until
+	// the command finishes
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		if err := cmd.Start(); err != nil {
+			// The job may have ended and its cgroup been cleaned up since it was read
+			if status := j.Status(); status != RunningStatus {
+				err = &StatusError{ID: j.id, Op: "execute command in", Status: status, Err: ErrJobNotRunning}
+			}
+
+			done <- result{exitCode: -1, err: err}
+
+			return
+		}
+
+		logger.Debug("Started command in job", "id", j.id, "pid", cmd.Process.Pid)
+
+		err := cmd.Wait()
+
+		var exitErr *exec.ExitError
+
+		if errors.As(err, &exitErr) || errors.Is(err, exec.ErrWaitDelay) {
+			// A non-zero exit code is reported through the exit code rather than as an error, and output that was
+			// still open after the command exited doesn't change how it exited
+			err = nil
+		}
+
+		done <- result{exitCode: cmd.ProcessState.ExitCode(), err: err}
+	}()
+
+	res := <-done
+
+	return res.exitCode, res.err
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"io"
+	"testing"
+)
+
+func TestJob_ExecCleanedUp(t *testing.T) {
+	cg, err := cgroups.NewCgroup(t.TempDir(), "worker", "job")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The job is still running while its cgroup is cleaned up once its command has exited
+	cg.Cleanup()
+
+	j := &Job{id: "job", status: RunningStatus, cgroup: cg, clock: &testClock{time: UnixEpoch()}}
+
+	if _, err := j.Exec(context.Background(), io.Discard, io.Discard, "true"); !errors.Is(err, ErrJobNotRunning) {
+		t.Errorf("Exec() error = %v, want %v", err, ErrJobNotRunning)
+	}
+}
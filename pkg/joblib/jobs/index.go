@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// indexBytesInterval Amount of output written between index entries.
+	indexBytesInterval = 64 * 1024
+	// indexTimeResolution Time between index entries; timestamps used to read output are resolved to this resolution.
+	indexTimeResolution = 1 * time.Second
+)
+
+// OutputRange Part of a job's output to read; when several bounds are given the output within all of them is read. The
+// zero value reads all the output.
+type OutputRange struct {
+	// TailLines Read only the last lines of output; zero means all lines are read.
+	TailLines int64
+	// Offset Byte offset to start reading output at.
+	Offset int64
+	// Limit Maximum number of bytes to read; zero means there is no maximum.
+	Limit int64
+	// Since Read only output written at or after this time.
+	Since time.Time
+	// Until Read only output written before this time.
+	Until time.Time
+}
+
+// Validate Ensure the range is valid.
+func (r OutputRange) Validate() error {
+	if r.TailLines < 0 {
+		return fmt.Errorf("tail lines cannot be negative: %d", r.TailLines)
+	}
+
+	if r.Offset < 0 {
+		return fmt.Errorf("offset cannot be negative: %d", r.Offset)
+	}
+
+	if r.Limit < 0 {
+		return fmt.Errorf("limit cannot be negative: %d", r.Limit)
+	}
+
+	if !r.Since.IsZero() && !r.Until.IsZero() && r.Until.Before(r.Since) {
+		return errors.New("until cannot be before since")
+	}
+
+	return nil
+}
+
+// indexEntry Position in a stream of output along with when it was written.
+type indexEntry struct {
+	offset int64
+	// lines Number of newlines written before the offset.
+	lines int64
+	time  time.Time
+}
+
+// outputIndex Sparse index of a stream of output that maps byte offsets to segments, line numbers and times so that
+// parts of the output can be read without reading everything written before them.
+type outputIndex struct {
+	mu            sync.RWMutex
+	entries       []indexEntry
+	segmentStarts []int64
+	size          int64
+	lines         int64
+	lastByte      byte
+}
+
+// newOutputIndex Create an index for an empty stream of output.
+func newOutputIndex() *outputIndex {
+	return &outputIndex{}
+}
+
+// startSegment Record that a new segment starts at the current end of the output.
+func (idx *outputIndex) startSegment() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.segmentStarts = append(idx.segmentStarts, idx.size)
+}
+
+// record Record that the data was written to the end of the output at the given time.
+func (idx *outputIndex) record(data []byte, now time.Time) {
+	if len(data) == 0 {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if n := len(idx.entries); n == 0 ||
+		idx.size-idx.entries[n-1].offset >= indexBytesInterval ||
+		now.Sub(idx.entries[n-1].time) >= indexTimeResolution {
+		idx.entries = append(idx.entries, indexEntry{offset: idx.size, lines: idx.lines, time: now})
+	}
+
+	idx.size += int64(len(data))
+	idx.lines += int64(bytes.Count(data, []byte{'\n'}))
+	idx.lastByte = data[len(data)-1]
+}
+
+// segment Returns the index of the segment containing the offset along with the offset at which that segment starts.
+func (idx *outputIndex) segment(offset int64) (int, int64) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	i := sort.Search(len(idx.segmentStarts), func(i int) bool { return idx.segmentStarts[i] > offset }) - 1
+
+	if i < 0 {
+		return 1, 0
+	}
+
+	return i + 1, idx.segmentStarts[i]
+}
+
+// offsetAt Returns the offset of the first output written at or after the time, or the end of the output if nothing
+// has been written since.
+func (idx *outputIndex) offsetAt(t time.Time) int64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	i := sort.Search(len(idx.entries), func(i int) bool { return !idx.entries[i].time.Before(t) })
+
+	if i == len(idx.entries) {
+		return idx.size
+	}
+
+	return idx.entries[i].offset
+}
+
+// lineStart Returns the closest indexed position before the line that follows the given number of newlines; false if
+// there is none.
+func (idx *outputIndex) lineStart(newlines int64) (indexEntry, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	i := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].lines >= newlines }) - 1
+
+	if i < 0 {
+		return indexEntry{}, false
+	}
+
+	return idx.entries[i], true
+}
+
+// tailOffset Returns the offset of the start of the last n lines of output, reading forward from the closest indexed
+// offset with open.
+func (idx *outputIndex) tailOffset(n int64, open func(offset int64) (io.ReadCloser, error)) (int64, error) {
+	idx.mu.RLock()
+	lines := idx.lines
+
+	// A final line without a newline is still a line
+	if idx.size > 0 && idx.lastByte != '\n' {
+		lines++
+	}
+
+	idx.mu.RUnlock()
+
+	skip := lines - n
+
+	if skip <= 0 {
+		return 0, nil
+	}
+
+	entry, ok := idx.lineStart(skip)
+
+	if !ok {
+		return 0, nil
+	}
+
+	r, err := open(entry.offset)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer r.Close()
+
+	reader := bufio.NewReader(r)
+	offset := entry.offset
+
+	for remaining := skip - entry.lines; remaining > 0; remaining-- {
+		line, err := reader.ReadSlice('\n')
+
+		for err == bufio.ErrBufferFull {
+			offset += int64(len(line))
+			line, err = reader.ReadSlice('\n')
+		}
+
+		offset += int64(len(line))
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return offset, nil
+}
+
+// bounds Returns the offsets of the start and end of the output within the range; the end is negative if the output
+// is read until its end.
+func (idx *outputIndex) bounds(r OutputRange, open func(offset int64) (io.ReadCloser, error)) (int64, int64, error) {
+	start := r.Offset
+	end := int64(-1)
+
+	if !r.Since.IsZero() {
+		start = max(start, idx.offsetAt(r.Since))
+	}
+
+	if r.TailLines > 0 {
+		tail, err := idx.tailOffset(r.TailLines, open)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		start = max(start, tail)
+	}
+
+	if !r.Until.IsZero() {
+		end = idx.offsetAt(r.Until)
+	}
+
+	if r.Limit > 0 && (end < 0 || start+r.Limit < end) {
+		end = start + r.Limit
+	}
+
+	if end >= 0 && end < start {
+		end = start
+	}
+
+	return start, end, nil
+}
+
+// timeAt Returns when the output at the offset was written, to the resolution of the index.
+func (idx *outputIndex) timeAt(offset int64) time.Time {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	i := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].offset > offset }) - 1
+
+	if i < 0 {
+		return time.Time{}
+	}
+
+	return idx.entries[i].time
+}
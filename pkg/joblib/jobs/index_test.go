@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestJob_Output(t *testing.T) {
+	start := UnixEpoch()
+
+	tests := []struct {
+		name        string
+		outputRange OutputRange
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "Should read all output",
+			outputRange: OutputRange{},
+			want:        "one\ntwo\nthree\nfour\nfive",
+		},
+		{
+			name:        "Should read the last lines",
+			outputRange: OutputRange{TailLines: 2},
+			want:        "four\nfive",
+		},
+		{
+			name:        "Should read every line when tailing more lines than there are",
+			outputRange: OutputRange{TailLines: 10},
+			want:        "one\ntwo\nthree\nfour\nfive",
+		},
+		{
+			name:        "Should read from an offset in a later segment",
+			outputRange: OutputRange{Offset: 9, Limit: 8},
+			want:        "hree\nfou",
+		},
+		{
+			name:        "Should read output written since a time",
+			outputRange: OutputRange{Since: start.Add(2 * time.Second)},
+			want:        "three\nfour\nfive",
+		},
+		{
+			name:        "Should read output written between two times",
+			outputRange: OutputRange{Since: start.Add(time.Second), Until: start.Add(3 * time.Second)},
+			want:        "two\nthree\n",
+		},
+		{
+			name:        "Should reject a negative offset",
+			outputRange: OutputRange{Offset: -1},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{time: start}
+
+			j := &Job{
+				clock:       clock,
+				outputDir:   t.TempDir(),
+				stdoutIndex: newOutputIndex(),
+				stderrIndex: newOutputIndex(),
+			}
+
+			policy := RotationPolicy{MaxSegmentBytes: 6, Compression: GzipCompression}
+			out, err := newSegmentWriter(j.outputDir, stdoutName, policy, clock, j.stdoutIndex)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errOut, err := newSegmentWriter(j.outputDir, stderrName, policy, clock, j.stderrIndex)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five"} {
+				if _, err := out.Write([]byte(line)); err != nil {
+					t.Fatal(err)
+				}
+
+				clock.time = clock.time.Add(time.Second)
+			}
+
+			out.close()
+			errOut.close()
+
+			stdout, stderr, err := j.Output(tt.outputRange)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Output() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			defer stdout.Close()
+			defer stderr.Close()
+
+			got, err := io.ReadAll(stdout)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("Output() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"syscall"
+)
+
+// Isolation Namespaces the job's command is run in, isolating it from the current process; the command shares every
+// namespace that isn't enabled with the current process. Creating namespaces requires the same privileges as cgroups.
+type Isolation struct {
+	// PID Run the command in a new PID namespace where it is PID 1. Only processes the command starts are visible
+	// to it, although /proc still shows the processes of the current PID namespace since it isn't remounted.
+	PID bool `json:"pid"`
+	// Mount Run the command in a new mount namespace whose mounts are private so that mounts made by the command
+	// aren't visible to the current process and vice versa.
+	Mount bool `json:"mount"`
+	// Network Run the command in a new network namespace, which only has a loopback interface that is down.
+	Network bool `json:"network"`
+}
+
+// apply Set the flags that create the enabled namespaces when the command is started.
+func (i Isolation) apply(attr *syscall.SysProcAttr) {
+	if i.PID {
+		attr.Cloneflags |= syscall.CLONE_NEWPID
+	}
+
+	// Unsharing the mount namespace rather than cloning it makes the command's mounts private
+	if i.Mount {
+		attr.Unshareflags |= syscall.CLONE_NEWNS
+	}
+
+	if i.Network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+}
+
+// enabled Returns true if the command is isolated in any namespace.
+func (i Isolation) enabled() bool {
+	return i.PID || i.Mount || i.Network
+}
+
+// Isolation Returns the namespaces the job's command is run in.
+func (j *Job) Isolation() Isolation {
+	return j.isolation
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestIsolation_apply(t *testing.T) {
+	tests := []struct {
+		name             string
+		isolation        Isolation
+		wantCloneflags   uintptr
+		wantUnshareflags uintptr
+	}{
+		{
+			name: "Should share every namespace by default",
+		},
+		{
+			name:           "Should clone the PID and network namespaces",
+			isolation:      Isolation{PID: true, Network: true},
+			wantCloneflags: syscall.CLONE_NEWPID | syscall.CLONE_NEWNET,
+		},
+		{
+			name:             "Should unshare the mount namespace",
+			isolation:        Isolation{Mount: true},
+			wantUnshareflags: syscall.CLONE_NEWNS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attr := &syscall.SysProcAttr{}
+
+			tt.isolation.apply(attr)
+
+			if attr.Cloneflags != tt.wantCloneflags {
+				t.Errorf("Cloneflags = %#x, want %#x", attr.Cloneflags, tt.wantCloneflags)
+			}
+
+			if attr.Unshareflags != tt.wantUnshareflags {
+				t.Errorf("Unshareflags = %#x, want %#x", attr.Unshareflags, tt.wantUnshareflags)
+			}
+		})
+	}
+}
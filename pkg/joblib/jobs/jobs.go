@@ -1,19 +1,30 @@
 package jobs
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/kurczynski/teleport-job-worker/internal/clock"
 	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
+const (
+	// umaskShell Shell that sets the umask of a job's command before replacing itself with the command.
+	umaskShell = "/bin/sh"
+	// umaskScript Script run by umaskShell; the umask is given as $0 and the command with its arguments as $@.
+	umaskScript = `umask "$0" && exec "$@"`
+)
+
 var (
 	// TODO: This should be loaded or injected, not hardcoded
 	logger = slog.New(slog.NewTextHandler(
@@ -31,13 +42,44 @@ const (
 	StoppedStatus   = Status("stopped")
 	FailedStatus    = Status("failed")
 	SucceededStatus = Status("succeeded")
+	// RestartingStatus Intermediate status of a job waiting to restart its command after it exited.
+	RestartingStatus = Status("restarting")
+	// QueuedStatus Status of a job waiting for its scheduler to have capacity to start it.
+	QueuedStatus = Status("queued")
+
+	// TimedOutInfo Status info of a job whose command was killed because it ran past its timeout or deadline.
+	TimedOutInfo = "timed out"
+	// PreemptedInfo Status info of a job that was stopped by its scheduler to make room for a job with a higher
+	// priority; followed by the ID of that job.
+	PreemptedInfo = "preempted by higher priority job"
 )
 
 // Status Status of the job.
 type Status string
 
+// isTerminal Returns true if the job cannot change status after it has this status.
+func isTerminal(status Status) bool {
+	return status == StoppedStatus || status == FailedStatus || status == SucceededStatus
+}
+
+// transitions Statuses that a job can change to from each status; a job cannot change status once it has a terminal
+// status. A running job changes to running again when only its status info changes, e.g. when it is reattached to.
+var transitions = map[Status][]Status{
+	"":               {ReadyStatus},
+	ReadyStatus:      {QueuedStatus, RunningStatus, StoppedStatus, FailedStatus},
+	QueuedStatus:     {RunningStatus, StoppedStatus, FailedStatus},
+	RunningStatus:    {RunningStatus, RestartingStatus, StoppedStatus, FailedStatus, SucceededStatus},
+	RestartingStatus: {RunningStatus, StoppedStatus, FailedStatus},
+}
+
+// CanTransition Returns true if a job with the status is allowed to change to the other status.
+func (s Status) CanTransition(to Status) bool {
+	return slices.Contains(transitions[s], to)
+}
+
 // Job Contains information to interact with jobs.
 type Job struct {
+	mu             sync.Mutex
 	id             string
 	created        time.Time
 	status         Status
@@ -46,51 +88,268 @@ type Job struct {
 	resourceLimits cgroups.Resources
 	cgroup         *cgroups.Cgroup
 	clock          clock.Clock
-	stdoutFilename string
-	stderrFilename string
+	outputDir      string
+	signalEvents   []SignalEvent
+	statusInfo     string
+	timeout        time.Duration
+	deadline       time.Time
+	timedOut       bool
+	stopped        bool
+	started        bool
+	halt           chan struct{}
+	haltOnce       sync.Once
+	// path Path of the job's command resolved when the job was created; the job's command may be run through a shell
+	path           string
+	name           string
+	args           []string
+	restartPolicy  RestartPolicy
+	runs           []Run
+	env            []string
+	dir            string
+	umask          *int
+	stdin          []byte
+	stdinStream    bool
+	stdinReader    *os.File
+	stdinWriter    *os.File
+	tty            bool
+	windowSize     WindowSize
+	ptyMaster      *os.File
+	ptySlave       *os.File
+	attachments    map[*Attachment]struct{}
+	maxOutputBytes int64
+	rotationPolicy RotationPolicy
+	outputFormat   OutputFormat
+	stdoutIndex    *outputIndex
+	stderrIndex    *outputIndex
+	ended          time.Time
+	lastAccessed   time.Time
+	outputDeleted  bool
+	store          Store
+	// saveMu Serializes saving the job so that its records are saved in the order they were taken
+	saveMu    sync.Mutex
+	orphaned  bool
+	scheduler *Scheduler
+	endHooks  []func()
+	priority  int
+	preempt   bool
+	owner     string
+	labels    map[string]string
+	isolation Isolation
+}
+
+// Options Optional settings for a job.
+type Options struct {
+	// Timeout Maximum amount of time the job's command can run before it is killed; zero means there is no timeout.
+	Timeout time.Duration
+	// Deadline Time by which the job's command must finish before it is killed; zero means there is no deadline.
+	Deadline time.Time
+	// RestartPolicy Describes if and how the job's command is restarted after it exits; never restarted by default.
+	RestartPolicy RestartPolicy
+	// Env Environment variables of the job's command in the form "KEY=value"; these take precedence over inherited
+	// variables.
+	Env []string
+	// InheritEnv Names of environment variables that the job's command inherits from the current process; every other
+	// variable is left out of the command's environment.
+	InheritEnv []string
+	// Dir Working directory of the job's command; the current process's working directory is used if empty.
+	Dir string
+	// Umask File mode creation mask of the job's command, which is set by running the command through /bin/sh; the
+	// current process's umask is used if nil.
+	Umask *int
+	// Stdin Data read by the job's command from stdin.
+	Stdin []byte
+	// StdinStream Stream data to the job's command's stdin with WriteStdin until CloseStdin is called; cannot be used
+	// along with Stdin.
+	StdinStream bool
+	// TTY Run the job's command with a pseudo-terminal as its stdin, stdout and stderr so that it can be attached to;
+	// cannot be used along with Stdin or StdinStream.
+	TTY bool
+	// WindowSize Initial size of the pseudo-terminal; the default size is used if zero.
+	WindowSize WindowSize
+	// OutputDir Directory that a directory storing the job's output is created in; DefaultOutputDir is used if empty.
+	// See ValidateOutputDir for the requirements of the directory.
+	OutputDir string
+	// MaxOutputBytes Maximum amount of output the job's command can write to stdout and stderr combined; output past
+	// the maximum is replaced with a truncation marker. Zero means there is no maximum.
+	MaxOutputBytes int64
+	// RotationPolicy Describes when the job's output is rotated into new segments and how closed segments are
+	// compressed; output is never rotated by default.
+	RotationPolicy RotationPolicy
+	// OutputFormat Format of the lines the job's command writes, which allows its output to be filtered by field when
+	// it is JSONFormat; TextFormat is used if empty.
+	OutputFormat OutputFormat
+	// Store Persists the job's metadata whenever it changes so that the job can be restored by a Reconciliation; the
+	// job's metadata is only kept in memory if nil.
+	Store Store
+	// Priority Order in which a scheduler starts queued jobs; jobs with a higher priority start first and jobs with the
+	// same priority start in the order they were submitted.
+	Priority int
+	// Preempt Allow a scheduler without capacity for the job to stop running jobs with a lower priority to make room
+	// for it.
+	Preempt bool
+	// Owner Identity of the client that started the job, which quotas are applied to; may be empty.
+	Owner string
+	// Labels Arbitrary key-value pairs describing the job, e.g. the team that owns it; see ValidateLabels for the
+	// requirements of keys and values.
+	Labels map[string]string
+	// Isolation Namespaces the job's command is run in; the command shares the namespaces of the current process by
+	// default.
+	Isolation Isolation
+}
+
+// validate Ensure the options can be used to run a job.
+func (o Options) validate() error {
+	if err := o.RestartPolicy.Validate(); err != nil {
+		return err
+	}
+
+	for _, env := range o.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			return fmt.Errorf("environment variable must be in the form KEY=value: %s", env)
+		}
+	}
+
+	if o.Dir != "" {
+		if info, err := os.Stat(o.Dir); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("working directory is not a directory: %s", o.Dir)
+		}
+	}
+
+	if o.Umask != nil && (*o.Umask < 0 || *o.Umask > 0777) {
+		return fmt.Errorf("invalid umask: %#o", *o.Umask)
+	}
+
+	if o.Stdin != nil && o.StdinStream {
+		return errors.New("stdin data and streamed stdin cannot be used together")
+	}
+
+	if err := o.RotationPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.OutputFormat.Validate(); err != nil {
+		return err
+	}
+
+	if o.MaxOutputBytes < 0 {
+		return fmt.Errorf("max output bytes cannot be negative: %d", o.MaxOutputBytes)
+	}
+
+	if o.TTY && (o.Stdin != nil || o.StdinStream) {
+		return errors.New("a terminal cannot be used along with stdin data or streamed stdin")
+	}
+
+	if err := ValidateLabels(o.Labels); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// environ Build the environment of the job's command from the inherited and explicitly set variables.
+func (o Options) environ() []string {
+	env := make([]string, 0, len(o.InheritEnv)+len(o.Env))
+
+	for _, key := range o.InheritEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+
+	// Later variables take precedence over earlier ones with the same key when the command is executed
+	return append(env, o.Env...)
 }
 
 // StatusChange When the status of the job was changed.
 type StatusChange struct {
 	Status    Status
 	ChangedAt time.Time
+	// Info Human-readable information about why the status changed; may be empty.
+	Info string
 }
 
-// NewJob Create a new job to run the specified command using the given resource limits.
-func NewJob(workerName string, clock clock.Clock, resourceLimits cgroups.Resources, command string, args ...string) (*Job, error) {
-	logger.Debug("Creating new job", "workerName", workerName, "resourceLimits", resourceLimits, "command", command, "args", args)
+// NewJob Create a new job to run the specified command using the given resource limits and options.
+func NewJob(workerName string, clock clock.Clock, resourceLimits cgroups.Resources, options Options, command string, args ...string) (*Job, error) {
+	// Options aren't logged since the environment and stdin may contain secrets
+	logger.Debug("Creating new job", "workerName", workerName, "resourceLimits", resourceLimits, "owner", options.Owner, "command", command, "args", args)
+
+	if err := options.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+	}
+
+	outputRoot := options.OutputDir
+
+	if outputRoot == "" {
+		outputRoot = DefaultOutputDir
+	}
 
 	id := uuid.NewString()
-	cg, err := cgroups.NewCgroup("/sys/fs/cgroup", workerName, id)
+	outputDir, err := createOutputDir(outputRoot, id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	cmd := exec.Command(command, args...)
+	cg, err := cgroups.NewCgroup(cgroups.DefaultRoot, workerName, id)
 
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		CgroupFD:    cg.FD(),
-		Pdeathsig:   syscall.SIGKILL,
-		Setpgid:     true,
-		UseCgroupFD: true,
+	if err != nil {
+		os.Remove(outputDir)
+
+		return nil, err
 	}
 
-	job := Job{
+	job := &Job{
 		id:             id,
-		command:        cmd,
 		created:        clock.Now(),
 		clock:          clock,
 		statusChanges:  make([]StatusChange, 0),
+		signalEvents:   make([]SignalEvent, 0),
 		resourceLimits: resourceLimits,
 		cgroup:         cg,
-		stdoutFilename: strings.Join([]string{"/tmp", fmt.Sprintf("%s-%s", id, "stdout")}, string(os.PathSeparator)),
-		stderrFilename: strings.Join([]string{"/tmp", fmt.Sprintf("%s-%s", id, "stderr")}, string(os.PathSeparator)),
+		outputDir:      outputDir,
+		timeout:        options.Timeout,
+		deadline:       options.Deadline,
+		halt:           make(chan struct{}),
+		name:           command,
+		args:           args,
+		restartPolicy:  options.RestartPolicy,
+		env:            options.environ(),
+		dir:            options.Dir,
+		umask:          options.Umask,
+		stdin:          options.Stdin,
+		stdinStream:    options.StdinStream,
+		tty:            options.TTY,
+		windowSize:     options.WindowSize,
+		maxOutputBytes: options.MaxOutputBytes,
+		rotationPolicy: options.RotationPolicy,
+		outputFormat:   options.OutputFormat,
+		store:          options.Store,
+		priority:       options.Priority,
+		preempt:        options.Preempt,
+		owner:          options.Owner,
+		labels:         maps.Clone(options.Labels),
+		isolation:      options.Isolation,
+		stdoutIndex:    newOutputIndex(),
+		stderrIndex:    newOutputIndex(),
+		runs:           make([]Run, 0),
 	}
 
+	// The pipe is created up front so that data streamed to a job that is queued is buffered until it runs
+	if err := job.openStdin(); err != nil {
+		cg.Cleanup()
+		os.Remove(outputDir)
+
+		return nil, err
+	}
+
+	job.command = job.newCommand()
+	// The command is resolved the same way exec.Command resolves it without the shell that sets the umask
+	job.path = exec.Command(command).Path
 	job.updateStatus(ReadyStatus)
 
-	return &job, nil
+	return job, nil
 }
 
 // ID Returns the ID of the job.
@@ -103,9 +362,43 @@ func (j *Job) Limits() cgroups.Resources {
 	return j.resourceLimits
 }
 
-// Command Returns the job's command with its arguments.
+// Priority Returns the order in which a scheduler starts the job relative to other queued jobs; higher priorities start
+// first.
+func (j *Job) Priority() int {
+	return j.priority
+}
+
+// Owner Returns the identity of the client that started the job; empty if unknown.
+func (j *Job) Owner() string {
+	return j.owner
+}
+
+// Dir Returns the working directory of the job's command; empty if it is the current process's working directory.
+func (j *Job) Dir() string {
+	return j.dir
+}
+
+// Umask Returns the file mode creation mask of the job's command; nil if it is the current process's umask.
+func (j *Job) Umask() *int {
+	return j.umask
+}
+
+// Command Returns the path of the job's command along with its arguments, the first being the command as it was given.
 func (j *Job) Command() (string, []string) {
-	return j.command.Path, j.command.Args
+	return j.path, append([]string{j.name}, j.args...)
+}
+
+// RestartPolicy Returns if and how the job's command is restarted after it exits.
+func (j *Job) RestartPolicy() RestartPolicy {
+	return j.restartPolicy
+}
+
+// Runs Returns every attempt at executing the job's command; the end of the last run is zero if it is still running.
+func (j *Job) Runs() []Run {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]Run(nil), j.runs...)
 }
 
 // Created Returns when the job was initially created.
@@ -113,20 +406,70 @@ func (j *Job) Created() time.Time {
 	return j.created
 }
 
+// Timeout Returns the maximum amount of time the job's command can run for; zero if there is no timeout.
+func (j *Job) Timeout() time.Duration {
+	return j.timeout
+}
+
+// Deadline Returns the time by which the job's command must finish; zero if there is no deadline.
+func (j *Job) Deadline() time.Time {
+	return j.deadline
+}
+
 // Status Returns the current status of the job.
 func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
 	return j.status
 }
 
+// StatusInfo Returns human-readable information about the current status of the job; may be empty.
+func (j *Job) StatusInfo() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.statusInfo
+}
+
+// Ended Returns when the job ended and true if the job has ended.
+func (j *Job) Ended() (time.Time, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.ended, isTerminal(j.status)
+}
+
 // StatusChanges Returns what status changes the job has gone through along with a timestamp of when.
 func (j *Job) StatusChanges() []StatusChange {
-	return j.statusChanges
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]StatusChange(nil), j.statusChanges...)
 }
 
-// Start Begin execution of the job's command immediately.
+// Start Begin execution of the job's command immediately; a job can only be started once, while it is ready or queued.
 func (j *Job) Start() error {
 	logger.Info("Starting job", "id", j.id, "command", j.command)
 
+	j.mu.Lock()
+
+	if status := j.status; j.started || (status != ReadyStatus && status != QueuedStatus) {
+		j.mu.Unlock()
+
+		return &StatusError{ID: j.id, Op: "start", Status: status, Err: ErrInvalidTransition}
+	}
+
+	j.started = true
+	j.mu.Unlock()
+
+	// The command's path is looked up when the command is created; the error is kept until the command is started
+	if err := j.command.Err; err != nil {
+		j.updateStatus(FailedStatus)
+
+		return fmt.Errorf("%w: %w", ErrExecutableNotFound, err)
+	}
+
 	if err := j.cgroup.Configure(j.resourceLimits); err != nil {
 		j.updateStatus(FailedStatus)
 
@@ -138,115 +481,428 @@ func (j *Job) Start() error {
 
 		defer runtime.UnlockOSThread()
 		defer j.cgroup.Cleanup()
+		defer j.killRemaining()
 
-		stdout, err := os.OpenFile(j.stdoutFilename, os.O_WRONLY|os.O_CREATE, 0644)
+		out, err := j.openOutput()
 
 		if err != nil {
-			logger.Error("Failed to open stdout", "err", err)
+			logger.Error("Failed to open output", "err", err)
 			j.updateStatus(FailedStatus)
 
 			return
 		}
 
-		defer stdout.Close()
+		defer out.close()
 
-		stderr, err := os.OpenFile(j.stderrFilename, os.O_WRONLY|os.O_CREATE, 0644)
-
-		if err != nil {
-			logger.Error("Failed to open stderr", "err", err)
+		// Terminal output is written to stdout so that it is available along with the output of other jobs
+		if err := j.openTerminal(out.stdoutWriter); err != nil {
+			logger.Error("Failed to open terminal", "err", err)
 			j.updateStatus(FailedStatus)
 
 			return
 		}
 
-		defer stderr.Close()
+		defer j.closeTerminal()
 
-		j.command.Stdout = stdout
-		j.command.Stderr = stderr
+		done := make(chan struct{})
+		defer close(done)
 
-		if err := j.command.Start(); err != nil {
-			logger.Error("Failed to start job", "err", err)
-			j.updateStatus(FailedStatus)
-
-			return
+		if limit, ok := j.timeLimit(); ok {
+			go j.enforceTimeLimit(limit, done)
 		}
 
-		j.updateStatus(RunningStatus)
+		for attempt := 1; ; attempt++ {
+			started, err := j.run(attempt, out)
 
-		logger.Debug("Started job command", "pid", j.command.Process.Pid)
+			if j.isStopped() {
+				return
+			}
 
-		if err := j.command.Wait(); err != nil {
-			logger.Error("Failed waiting for command to finish", "err", err)
-			j.updateStatus(FailedStatus)
+			if j.hasTimedOut() {
+				logger.Info("Job timed out", "id", j.id)
+				j.updateStatusWithInfo(FailedStatus, TimedOutInfo)
 
-			return
-		}
+				return
+			}
 
-		j.updateStatus(SucceededStatus)
+			if !started {
+				logger.Error("Failed to start job", "err", err)
+				j.updateStatus(FailedStatus)
+
+				return
+			}
+
+			if !j.restartPolicy.shouldRestart(err == nil, attempt) {
+				if err != nil {
+					logger.Error("Failed waiting for command to finish", "err", err)
+					j.updateStatus(FailedStatus)
+
+					return
+				}
+
+				j.updateStatus(SucceededStatus)
+
+				return
+			}
+
+			backoff := j.restartPolicy.backoff(attempt)
+
+			logger.Info("Restarting job", "id", j.id, "attempt", attempt, "backoff", backoff, "err", err)
+			j.updateStatusWithInfo(RestartingStatus, fmt.Sprintf("attempt %d ended, restarting in %s", attempt, backoff))
+
+			select {
+			case <-j.clock.After(backoff):
+			case <-j.halt:
+				if j.hasTimedOut() {
+					j.updateStatusWithInfo(FailedStatus, TimedOutInfo)
+				}
+
+				return
+			}
+		}
 	}()
 
 	return nil
 }
 
-// Stop End execution of the job immediately.
+// run Execute a single attempt of the job's command and wait for it to finish, recording the attempt in the job's runs.
+// Returns false if the command could not be started.
+func (j *Job) run(attempt int, out *output) (bool, error) {
+	cmd := j.command
+
+	if attempt > 1 {
+		cmd = j.newCommand()
+	}
+
+	out.set(cmd)
+	j.setStdin(cmd)
+
+	if j.tty {
+		j.setTerminal(cmd)
+	}
+
+	j.mu.Lock()
+
+	// The job may have been stopped or timed out while waiting to restart
+	if j.stopped || j.timedOut {
+		j.mu.Unlock()
+
+		return false, nil
+	}
+
+	j.command = cmd
+	j.runs = append(j.runs, Run{
+		Attempt:   attempt,
+		StartedAt: j.clock.Now(),
+		ExitCode:  -1,
+		Stdout:    Segment{Offset: out.stdout.offset()},
+		Stderr:    Segment{Offset: out.stderr.offset()},
+	})
+
+	err := cmd.Start()
+
+	if err == nil {
+		j.runs[len(j.runs)-1].PID = cmd.Process.Pid
+	}
+
+	j.mu.Unlock()
+
+	if err == nil {
+		j.updateStatus(RunningStatus)
+
+		logger.Debug("Started job command", "pid", cmd.Process.Pid, "attempt", attempt)
+
+		err = out.wait(cmd)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	run := &j.runs[len(j.runs)-1]
+	run.EndedAt = j.clock.Now()
+	run.Stdout.Length = out.stdout.offset() - run.Stdout.Offset
+	run.Stderr.Length = out.stderr.offset() - run.Stderr.Offset
+
+	if cmd.ProcessState != nil {
+		run.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	return cmd.ProcessState != nil, err
+}
+
+// setUmask Run the command through a shell that sets the job's umask, if it has one, before replacing itself with the
+// command. The umask belongs to the whole process, so changing it in the current process while the command is started
+// would also apply to files created by other goroutines at the same time.
+func (j *Job) setUmask(cmd *exec.Cmd) {
+	// A command that couldn't be found fails to start with the error from looking it up
+	if j.umask == nil || cmd.Err != nil {
+		return
+	}
+
+	cmd.Args = append([]string{umaskShell, "-c", umaskScript, fmt.Sprintf("%04o", *j.umask), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = umaskShell
+}
+
+// killRemaining Kill processes left in the job's cgroup after the job's command exited, e.g. commands started by Exec,
+// so that the cgroup can be removed.
+func (j *Job) killRemaining() {
+	if err := j.cgroup.Kill(); err != nil {
+		logger.Warn("Failed to kill remaining processes in job", "id", j.id, "err", err)
+	}
+}
+
+// newCommand Create the command that is executed by the job.
+func (j *Job) newCommand() *exec.Cmd {
+	cmd := exec.Command(j.name, j.args...)
+	cmd.Env = j.env
+	cmd.Dir = j.dir
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CgroupFD:    j.cgroup.FD(),
+		Pdeathsig:   syscall.SIGKILL,
+		Setpgid:     true,
+		UseCgroupFD: true,
+	}
+
+	j.isolation.apply(cmd.SysProcAttr)
+	j.setUmask(cmd)
+
+	return cmd
+}
+
+// process Returns the process of the job's current command; nil if it hasn't been started.
+func (j *Job) process() *os.Process {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.command.Process
+}
+
+// Discard Remove everything created for a job that could not be started: its cgroup, its output directory and its
+// record in the store. The job must not be used afterwards.
+func (j *Job) Discard() {
+	logger.Info("Discarding job", "id", j.id)
+
+	j.cgroup.Cleanup()
+
+	if err := os.RemoveAll(j.outputDir); err != nil {
+		logger.Warn("Failed to remove output of discarded job", "id", j.id, "err", err)
+	}
+
+	if j.store == nil {
+		return
+	}
+
+	// Saves that are in progress finish first so that they cannot add the record back
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	if err := j.store.Delete(j.id); err != nil {
+		logger.Error("Failed to delete discarded job from store", "id", j.id, "err", err)
+	}
+}
+
+// Stop End execution of the job immediately; a job waiting to be restarted will not be restarted. Stopping a job that
+// was already stopped has no effect, while stopping a job that ended otherwise returns an error.
 func (j *Job) Stop() error {
-	logger.Info("Stopping job", "id", j.id, "command", j.command)
+	return j.stop("")
+}
+
+// stop End execution of the job immediately, giving the reason it was stopped as the status info.
+func (j *Job) stop(info string) error {
+	logger.Info("Stopping job", "id", j.id, "command", j.command, "info", info)
+
+	j.mu.Lock()
+	status := j.status
+
+	if j.stopped || status == StoppedStatus {
+		j.mu.Unlock()
+
+		return nil
+	}
+
+	// Restored jobs have already ended and have no cgroup
+	if isTerminal(status) {
+		j.mu.Unlock()
+
+		return &StatusError{ID: j.id, Op: "stop", Status: status, Err: ErrJobEnded}
+	}
+
+	j.stopped = true
+	j.mu.Unlock()
 
 	defer j.cgroup.Cleanup()
-	defer j.cleanup()
 
-	if err := j.command.Process.Kill(); err != nil {
+	j.interrupt()
+
+	// The command of a reattached job may have exited while other processes in its cgroup are still running
+	if j.isOrphaned() {
+		if err := j.cgroup.Kill(); err != nil {
+			j.updateStatus(FailedStatus)
+
+			return err
+		}
+
+		// The cgroup can only be cleaned up once the killed processes have exited
+		waitUnpopulated(j.cgroup, j.clock)
+		j.updateStatusWithInfo(StoppedStatus, info)
+
+		return nil
+	}
+
+	// Neither a job waiting to restart nor a job whose command hasn't been started has a process to kill; a command is
+	// never started once the job is marked as stopped
+	if j.Status() == RestartingStatus || j.process() == nil {
+		j.updateStatusWithInfo(StoppedStatus, info)
+
+		return nil
+	}
+
+	// The command may have exited on its own since the job's status was checked
+	if err := j.process().Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
 		j.updateStatus(FailedStatus)
 
 		return err
 	} else {
-		j.updateStatus(StoppedStatus)
+		j.updateStatusWithInfo(StoppedStatus, info)
 
 		return nil
 	}
 }
 
-// Output Get the full output (stdout and stderr) from the job.
-func (j *Job) Output() (*os.File, *os.File, error) {
-	logger.Debug("Getting job output")
+// timeLimit Returns how long the job's command is allowed to run for based on its timeout and deadline, if either is
+// set.
+func (j *Job) timeLimit() (time.Duration, bool) {
+	limit := j.timeout
 
-	var stdout *os.File
-	var stderr *os.File
+	if !j.deadline.IsZero() {
+		untilDeadline := j.deadline.Sub(j.clock.Now())
 
-	if fout, err := os.Open(j.stdoutFilename); err != nil {
-		return nil, nil, err
-	} else {
-		stdout = fout
+		if limit == 0 || untilDeadline < limit {
+			limit = max(untilDeadline, 0)
+		}
+
+		return limit, true
 	}
 
-	logger.Debug("Opened stdout", "path", stdout.Name())
+	return limit, limit > 0
+}
 
-	if ferr, err := os.Open(j.stderrFilename); err != nil {
-		return nil, nil, err
-	} else {
-		stderr = ferr
+// enforceTimeLimit Kill the job's process group if it is still running after the time limit has elapsed.
+func (j *Job) enforceTimeLimit(limit time.Duration, done <-chan struct{}) {
+	select {
+	case <-j.clock.After(limit):
+		j.mu.Lock()
+		j.timedOut = true
+		j.mu.Unlock()
+
+		j.interrupt()
+
+		logger.Info("Killing job that exceeded its time limit", "id", j.id, "limit", limit)
+
+		if j.Status() != RunningStatus {
+			return
+		}
+
+		if err := syscall.Kill(-j.process().Pid, syscall.SIGKILL); err != nil {
+			logger.Error("Failed to kill timed out job", "id", j.id, "err", err)
+		}
+	case <-done:
 	}
+}
 
-	logger.Debug("Opened stderr", "path", stderr.Name())
+// interrupt Wake up the job if it is waiting to restart its command so that it can end.
+func (j *Job) interrupt() {
+	j.haltOnce.Do(func() {
+		close(j.halt)
+	})
+}
 
-	return stdout, stderr, nil
+// isStopped Returns true if the job was stopped by a user.
+func (j *Job) isStopped() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.stopped
+}
+
+// hasTimedOut Returns true if the job's command was killed for exceeding its time limit.
+func (j *Job) hasTimedOut() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.timedOut
 }
 
-// updateStatus Update the job's status and record the time when it changed.
-func (j *Job) updateStatus(status Status) {
+// updateStatus Update the job's status and record the time when it changed; see updateStatusWithInfo.
+func (j *Job) updateStatus(status Status) error {
+	return j.updateStatusWithInfo(status, "")
+}
+
+// updateStatusWithInfo Update the job's status along with information giving more context about the status, saving the
+// job to its store. Returns an error without changing the status if the job cannot change to it, e.g. if it has ended.
+func (j *Job) updateStatusWithInfo(status Status, info string) error {
+	j.mu.Lock()
+
+	if from := j.status; !from.CanTransition(status) {
+		j.mu.Unlock()
+		logger.Debug("Ignoring invalid job status transition", "id", j.id, "from", from, "to", status)
+
+		return &StatusError{
+			ID: j.id, Op: "change status of", Status: from, Err: fmt.Errorf("%w to %s", ErrInvalidTransition, status),
+		}
+	}
+
 	now := j.clock.Now()
 	j.status = status
+	j.statusInfo = info
 
-	j.statusChanges = append(j.statusChanges, StatusChange{Status: status, ChangedAt: now})
-}
+	if isTerminal(status) {
+		j.ended = now
+	}
+
+	j.statusChanges = append(j.statusChanges, StatusChange{Status: status, ChangedAt: now, Info: info})
+	scheduler := j.scheduler
+	j.mu.Unlock()
+
+	j.save()
 
-// cleanup Cleanup files used to store output from the job's command.
-func (j *Job) cleanup() {
-	if err := os.Remove(j.stdoutFilename); err != nil {
-		logger.Error("Failed to remove stdout", "path", j.stdoutFilename)
+	if !isTerminal(status) {
+		return nil
+	}
+
+	// The command of a job that has ended won't be executed again, including a job that ended before it ran
+	j.closeStdinPipe()
+
+	if scheduler != nil {
+		scheduler.release(j)
+	}
+
+	j.mu.Lock()
+	hooks := j.endHooks
+	j.endHooks = nil
+	j.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
 	}
 
-	if err := os.Remove(j.stderrFilename); err != nil {
-		logger.Error("Failed to remove stderr", "path", j.stderrFilename)
+	return nil
+}
+
+// onEnd Call the function once the job has ended, right away if it already has. It is called after the job's
+// scheduler has released the job's capacity.
+func (j *Job) onEnd(hook func()) {
+	j.mu.Lock()
+
+	if !isTerminal(j.status) {
+		j.endHooks = append(j.endHooks, hook)
+		j.mu.Unlock()
+
+		return
 	}
+
+	j.mu.Unlock()
+
+	hook()
 }
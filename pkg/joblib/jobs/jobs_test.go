@@ -1,8 +1,12 @@
 package jobs
 
 import (
+	"errors"
+	"fmt"
 	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"os/exec"
 	"reflect"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -15,6 +19,13 @@ func (tc *testClock) Now() time.Time {
 	return tc.time
 }
 
+func (tc *testClock) After(d time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	c <- tc.time.Add(d)
+
+	return c
+}
+
 func UnixEpoch() time.Time {
 	return time.UnixMilli(0).UTC()
 }
@@ -22,6 +33,7 @@ func UnixEpoch() time.Time {
 // TODO: Add some more tests like this to ensure actions on a job work properly
 func TestJob_updateStatus(t *testing.T) {
 	type fields struct {
+		status        Status
 		statusChanges []StatusChange
 		clock         clock.Clock
 	}
@@ -29,10 +41,11 @@ func TestJob_updateStatus(t *testing.T) {
 		status Status
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		want   []StatusChange
+		name    string
+		fields  fields
+		args    args
+		want    []StatusChange
+		wantErr bool
 	}{
 		{
 			name: "Should update initial job status",
@@ -46,6 +59,7 @@ func TestJob_updateStatus(t *testing.T) {
 		{
 			name: "Should update existing job status",
 			fields: fields{
+				status: RunningStatus,
 				statusChanges: []StatusChange{
 					{Status: RunningStatus, ChangedAt: UnixEpoch()},
 				},
@@ -57,14 +71,47 @@ func TestJob_updateStatus(t *testing.T) {
 				{Status: StoppedStatus, ChangedAt: UnixEpoch()},
 			},
 		},
+		{
+			name: "Should not update status of job that has ended",
+			fields: fields{
+				status: StoppedStatus,
+				statusChanges: []StatusChange{
+					{Status: StoppedStatus, ChangedAt: UnixEpoch()},
+				},
+				clock: &testClock{time: UnixEpoch()},
+			},
+			args:    args{status: FailedStatus},
+			want:    []StatusChange{{Status: StoppedStatus, ChangedAt: UnixEpoch()}},
+			wantErr: true,
+		},
+		{
+			name: "Should not update status of ready job to succeeded",
+			fields: fields{
+				status: ReadyStatus,
+				statusChanges: []StatusChange{
+					{Status: ReadyStatus, ChangedAt: UnixEpoch()},
+				},
+				clock: &testClock{time: UnixEpoch()},
+			},
+			args:    args{status: SucceededStatus},
+			want:    []StatusChange{{Status: ReadyStatus, ChangedAt: UnixEpoch()}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			j := &Job{
+				status:        tt.fields.status,
 				statusChanges: tt.fields.statusChanges,
 				clock:         tt.fields.clock,
 			}
-			j.updateStatus(tt.args.status)
+			err := j.updateStatus(tt.args.status)
+
+			if !errors.Is(err, ErrInvalidTransition) && tt.wantErr {
+				t.Errorf("updateStatus() error = %v, want %v", err, ErrInvalidTransition)
+			} else if err != nil && !tt.wantErr {
+				t.Errorf("updateStatus() error = %v, want nil", err)
+			}
 
 			if got := j.statusChanges; !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("updateStatus() = %v, want %v", j.statusChanges, tt.want)
@@ -72,3 +119,231 @@ func TestJob_updateStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestJob_timeLimit(t *testing.T) {
+	type fields struct {
+		timeout  time.Duration
+		deadline time.Time
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "Should not have a time limit without a timeout or deadline",
+			fields: fields{},
+			want:   0,
+			wantOK: false,
+		},
+		{
+			name:   "Should use timeout without a deadline",
+			fields: fields{timeout: time.Minute},
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "Should use deadline without a timeout",
+			fields: fields{deadline: UnixEpoch().Add(time.Hour)},
+			want:   time.Hour,
+			wantOK: true,
+		},
+		{
+			name:   "Should use deadline when it is sooner than the timeout",
+			fields: fields{timeout: time.Hour, deadline: UnixEpoch().Add(time.Minute)},
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "Should use timeout when it is sooner than the deadline",
+			fields: fields{timeout: time.Minute, deadline: UnixEpoch().Add(time.Hour)},
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "Should not have a negative time limit when the deadline has passed",
+			fields: fields{deadline: UnixEpoch().Add(-time.Hour)},
+			want:   0,
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &Job{
+				timeout:  tt.fields.timeout,
+				deadline: tt.fields.deadline,
+				clock:    &testClock{time: UnixEpoch()},
+			}
+
+			got, ok := j.timeLimit()
+
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("timeLimit() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJob_enforceTimeLimit(t *testing.T) {
+	cmd := exec.Command("sleep", "60")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	j := &Job{
+		command: cmd,
+		status:  RunningStatus,
+		clock:   &testClock{time: UnixEpoch()},
+		halt:    make(chan struct{}),
+	}
+
+	j.enforceTimeLimit(time.Minute, make(chan struct{}))
+
+	if !j.hasTimedOut() {
+		t.Errorf("hasTimedOut() = false, want true")
+	}
+
+	if err := cmd.Wait(); err == nil {
+		t.Errorf("Wait() error = nil, want command to have been killed")
+	}
+}
+
+func TestOptions_environ(t *testing.T) {
+	t.Setenv("JOBS_TEST_INHERITED", "inherited")
+	t.Setenv("JOBS_TEST_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		options Options
+		want    []string
+	}{
+		{
+			name:    "Should have a clean environment by default",
+			options: Options{},
+			want:    []string{},
+		},
+		{
+			name:    "Should only inherit allowed variables",
+			options: Options{InheritEnv: []string{"JOBS_TEST_INHERITED", "JOBS_TEST_UNSET"}},
+			want:    []string{"JOBS_TEST_INHERITED=inherited"},
+		},
+		{
+			name: "Should put explicit variables after inherited variables",
+			options: Options{
+				InheritEnv: []string{"JOBS_TEST_INHERITED"},
+				Env:        []string{"JOBS_TEST_INHERITED=explicit", "OTHER=value"},
+			},
+			want: []string{"JOBS_TEST_INHERITED=inherited", "JOBS_TEST_INHERITED=explicit", "OTHER=value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.environ(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("environ() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus_CanTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from Status
+		to   Status
+		want bool
+	}{
+		{name: "Should start a ready job", from: ReadyStatus, to: RunningStatus, want: true},
+		{name: "Should queue a ready job", from: ReadyStatus, to: QueuedStatus, want: true},
+		{name: "Should stop a queued job", from: QueuedStatus, to: StoppedStatus, want: true},
+		{name: "Should restart a running job", from: RunningStatus, to: RestartingStatus, want: true},
+		{name: "Should not queue a running job", from: RunningStatus, to: QueuedStatus, want: false},
+		{name: "Should not succeed without running", from: ReadyStatus, to: SucceededStatus, want: false},
+		{name: "Should not change a stopped job", from: StoppedStatus, to: FailedStatus, want: false},
+		{name: "Should not restart a succeeded job", from: SucceededStatus, to: RunningStatus, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Errorf("CanTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_Stop(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     *Job
+		wantErr error
+	}{
+		{
+			name: "Should stop a stopped job again",
+			job:  &Job{status: StoppedStatus},
+		},
+		{
+			name: "Should stop a job that is being stopped again",
+			job:  &Job{status: RunningStatus, stopped: true},
+		},
+		{
+			name:    "Should not stop a failed job",
+			job:     &Job{status: FailedStatus},
+			wantErr: ErrJobEnded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.job.Stop(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Stop() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJob_setUmask(t *testing.T) {
+	umask := 0027
+
+	tests := []struct {
+		name  string
+		umask *int
+		want  string
+	}{
+		{
+			name: "Should not change the umask by default",
+			want: fmt.Sprintf("%04o\n", currentUmask()),
+		},
+		{
+			name:  "Should set the umask in the command",
+			umask: &umask,
+			want:  "0027\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &Job{umask: tt.umask}
+			cmd := exec.Command("sh", "-c", "umask")
+
+			j.setUmask(cmd)
+
+			out, err := cmd.Output()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(out) != tt.want {
+				t.Errorf("umask = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+// currentUmask Returns the umask of the current process.
+func currentUmask() int {
+	umask := syscall.Umask(0)
+	syscall.Umask(umask)
+
+	return umask
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+)
+
+const (
+	// maxLabelKeyLength Maximum length of the key of a label.
+	maxLabelKeyLength = 63
+	// maxLabelValueLength Maximum length of the value of a label.
+	maxLabelValueLength = 253
+)
+
+// labelKeyPattern Label keys start and end with a letter or digit and may contain dots, dashes, underscores and slashes
+// in between, e.g. "team", "app.kubernetes.io/name".
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+
+// ValidateLabels Ensure every label has a valid key and a value that isn't too long; values may be empty.
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if len(key) > maxLabelKeyLength || !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key: %q", key)
+		}
+
+		if len(value) > maxLabelValueLength {
+			return fmt.Errorf("value of label %s is longer than %d characters", key, maxLabelValueLength)
+		}
+	}
+
+	return nil
+}
+
+// Labels Returns the labels the job was started with; empty if it has none.
+func (j *Job) Labels() map[string]string {
+	return maps.Clone(j.labels)
+}
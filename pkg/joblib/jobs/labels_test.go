@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "Should allow keys with dots, dashes, underscores and slashes",
+			labels: map[string]string{"team": "storage", "app.example.com/tier": "backend", "run_id": ""},
+		},
+		{
+			name: "Should allow no labels",
+		},
+		{
+			name:    "Should not allow an empty key",
+			labels:  map[string]string{"": "storage"},
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow a key that ends with a separator",
+			labels:  map[string]string{"team-": "storage"},
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow a key with spaces",
+			labels:  map[string]string{"the team": "storage"},
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow a key that is too long",
+			labels:  map[string]string{strings.Repeat("a", maxLabelKeyLength+1): "storage"},
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow a value that is too long",
+			labels:  map[string]string{"team": strings.Repeat("a", maxLabelValueLength+1)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLabels(tt.labels); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
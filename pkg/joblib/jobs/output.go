@@ -0,0 +1,389 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultOutputDir Directory that the output of jobs is stored in when no other directory is given.
+	DefaultOutputDir = "/var/lib/job-worker/output"
+
+	stdoutName = "stdout"
+	stderrName = "stderr"
+
+	// outputWaitDelay How long to wait for output to be copied after a command exits; this keeps
+	// processes that inherited the command's stdout or stderr from blocking the job.
+	outputWaitDelay = 1 * time.Second
+)
+
+var (
+	// ErrOutputDeleted Returned when getting the output of a job whose output has been deleted.
+	ErrOutputDeleted = errors.New("job output has been deleted")
+)
+
+// output Files that store the output of the job's command along with the writers used to write to them.
+type output struct {
+	stdout       *segmentWriter
+	stderr       *segmentWriter
+	stdoutWriter io.Writer
+	stderrWriter io.Writer
+}
+
+// limitedReadCloser Reads a limited amount from a reader that has to be closed.
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// outputLimit Amount of output that can be written by a job; shared by stdout and stderr.
+type outputLimit struct {
+	max     int64
+	written atomic.Int64
+	// truncated Whether the limit was reached, which is only reported by one truncation marker per job
+	truncated atomic.Bool
+}
+
+// limitedWriter Writes to a writer until the output limit is reached, at which point a truncation marker is written to
+// the writer that reached it and everything else written by the job is discarded.
+type limitedWriter struct {
+	w     io.Writer
+	limit *outputLimit
+}
+
+var (
+	// unsuitableFilesystems Pseudo-filesystems that output cannot be stored on, keyed by their magic number.
+	unsuitableFilesystems = map[int64]string{
+		unix.PROC_SUPER_MAGIC:    "proc",
+		unix.SYSFS_MAGIC:         "sysfs",
+		unix.CGROUP_SUPER_MAGIC:  "cgroup",
+		unix.CGROUP2_SUPER_MAGIC: "cgroup2",
+		unix.DEVPTS_SUPER_MAGIC:  "devpts",
+		unix.DEBUGFS_MAGIC:       "debugfs",
+	}
+)
+
+// ValidateOutputDir Ensure the directory can be used to store the output of jobs, creating it if it doesn't exist. The
+// directory must be owned by the current user, must not be writable by anyone else and must be on a regular filesystem.
+func ValidateOutputDir(dir string) error {
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("output directory must be an absolute path: %s", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(dir)
+
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("output directory is not a directory: %s", dir)
+	}
+
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("output directory must not be writable by group or others: %s has mode %s", dir, info.Mode().Perm())
+	}
+
+	if stat, ok := info.Sys().(*unix.Stat_t); ok && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("output directory must be owned by the current user: %s is owned by %d", dir, stat.Uid)
+	}
+
+	var fs unix.Statfs_t
+
+	if err := unix.Statfs(dir, &fs); err != nil {
+		return err
+	}
+
+	if name, ok := unsuitableFilesystems[int64(fs.Type)]; ok {
+		return fmt.Errorf("output directory is on a %s filesystem: %s", name, dir)
+	}
+
+	if int64(fs.Type) == unix.TMPFS_MAGIC {
+		logger.Warn("Output directory is on a tmpfs filesystem, output will be kept in memory", "path", dir)
+	}
+
+	return nil
+}
+
+// createOutputDir Create the directory that stores the output of the job; only the current user can access it.
+func createOutputDir(root string, id string) (string, error) {
+	dir := filepath.Join(root, id)
+
+	// Mkdir fails if the directory already exists so the output of another job is never reused
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return "", err
+	}
+
+	// Ensure the permissions aren't loosened by the umask of the current process
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", errors.Join(err, os.Remove(dir))
+	}
+
+	return dir, nil
+}
+
+// createOutputFile Create a file that stores the output of the job; fails if the file already exists.
+func createOutputFile(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+}
+
+// openOutput Create the first segments of the files that store the output of the job.
+func (j *Job) openOutput() (*output, error) {
+	stdout, err := newSegmentWriter(j.outputDir, stdoutName, j.rotationPolicy, j.clock, j.stdoutIndex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stderr, err := newSegmentWriter(j.outputDir, stderrName, j.rotationPolicy, j.clock, j.stderrIndex)
+
+	if err != nil {
+		stdout.close()
+
+		return nil, err
+	}
+
+	out := &output{
+		stdout:       stdout,
+		stderr:       stderr,
+		stdoutWriter: stdout,
+		stderrWriter: stderr,
+	}
+
+	if j.maxOutputBytes > 0 {
+		limit := &outputLimit{max: j.maxOutputBytes}
+
+		out.stdoutWriter = &limitedWriter{w: out.stdoutWriter, limit: limit}
+		out.stderrWriter = &limitedWriter{w: out.stderrWriter, limit: limit}
+	}
+
+	return out, nil
+}
+
+// set Write the command's stdout and stderr to the output.
+func (o *output) set(cmd *exec.Cmd) {
+	cmd.Stdout = o.stdoutWriter
+	cmd.Stderr = o.stderrWriter
+	cmd.WaitDelay = outputWaitDelay
+}
+
+// wait Wait for the command to exit and for its output to be copied. Processes that inherited the command's stdout or
+// stderr, e.g. ones it started in the background, can keep them open after the command exits; their output is no longer
+// copied once outputWaitDelay has passed and the command's own exit status decides whether it succeeded.
+func (o *output) wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+
+	if errors.Is(err, exec.ErrWaitDelay) {
+		logger.Warn("Output was still open after the command exited", "pid", cmd.Process.Pid)
+
+		return nil
+	}
+
+	return err
+}
+
+// close Close the files that store the output, waiting for closed segments to be compressed.
+func (o *output) close() {
+	o.stdout.close()
+	o.stderr.close()
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.limit.truncated.Load() {
+		return len(p), nil
+	}
+
+	size := int64(len(p))
+	written := w.limit.written.Add(size)
+
+	if written <= w.limit.max {
+		return w.w.Write(p)
+	}
+
+	// Only the first write past the limit writes the marker, even when stdout and stderr reach it at the same time
+	if !w.limit.truncated.CompareAndSwap(false, true) {
+		return len(p), nil
+	}
+
+	if remaining := size - (written - w.limit.max); remaining > 0 {
+		if _, err := w.w.Write(p[:remaining]); err != nil {
+			return 0, err
+		}
+	}
+
+	marker := fmt.Sprintf("\n[output truncated: job exceeded its output limit of %d bytes]\n", w.limit.max)
+
+	if _, err := io.WriteString(w.w, marker); err != nil {
+		return 0, err
+	}
+
+	// Report everything as written so the command doesn't fail because its output was truncated
+	return len(p), nil
+}
+
+// OutputDir Returns the directory that the output of the job is stored in.
+func (j *Job) OutputDir() string {
+	return j.outputDir
+}
+
+// Output Get the output (stdout and stderr) from the job within the range; the output is read across every segment. If
+// any field filters are given, only the lines within the range that match every filter are read; this requires the job's
+// output to be in JSONFormat.
+func (j *Job) Output(r OutputRange, filters ...FieldFilter) (io.ReadCloser, io.ReadCloser, error) {
+	logger.Debug("Getting job output", "range", r, "filters", filters)
+
+	if err := r.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(filters) > 0 && j.OutputFormat() != JSONFormat {
+		return nil, nil, ErrNotStructured
+	}
+
+	j.mu.Lock()
+
+	if j.outputDeleted {
+		j.mu.Unlock()
+
+		return nil, nil, ErrOutputDeleted
+	}
+
+	j.lastAccessed = j.clock.Now()
+	j.mu.Unlock()
+
+	stdout, err := j.openStream(stdoutName, j.stdoutIndex, r)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Debug("Opened stdout", "path", j.outputDir)
+
+	stderr, err := j.openStream(stderrName, j.stderrIndex, r)
+
+	if err != nil {
+		stdout.Close()
+
+		return nil, nil, err
+	}
+
+	logger.Debug("Opened stderr", "path", j.outputDir)
+
+	if len(filters) > 0 {
+		return newFilterReader(stdout, j.outputFormat, filters), newFilterReader(stderr, j.outputFormat, filters), nil
+	}
+
+	return stdout, stderr, nil
+}
+
+// openStream Open a stream of the job's output for reading within the range.
+func (j *Job) openStream(stream string, index *outputIndex, r OutputRange) (io.ReadCloser, error) {
+	open := func(offset int64) (io.ReadCloser, error) {
+		return openSegments(j.outputDir, stream, index, offset)
+	}
+
+	start, end, err := index.bounds(r, open)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := open(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end < 0 {
+		return reader, nil
+	}
+
+	return &limitedReadCloser{Reader: io.LimitReader(reader, end-start), Closer: reader}, nil
+}
+
+// OutputSize Returns the amount of disk space in bytes used by the output of the job.
+func (j *Job) OutputSize() int64 {
+	var size int64
+
+	entries, err := os.ReadDir(j.outputDir)
+
+	if err != nil {
+		return 0
+	}
+
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+
+	return size
+}
+
+// LastAccessed Returns when the output of the job was last used, i.e. when it was last read or when the job ended.
+func (j *Job) LastAccessed() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	latest := j.created
+
+	for _, t := range []time.Time{j.ended, j.lastAccessed} {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest
+}
+
+// OutputDeleted Returns true if the output of the job has been deleted.
+func (j *Job) OutputDeleted() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.outputDeleted
+}
+
+// DeleteOutput Delete the output of the job; the job must have ended.
+func (j *Job) DeleteOutput() error {
+	if err := j.deleteOutput(); err != nil {
+		return err
+	}
+
+	j.save()
+
+	return nil
+}
+
+// deleteOutput Delete the output of the job without saving the job.
+func (j *Job) deleteOutput() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !isTerminal(j.status) {
+		return &StatusError{ID: j.id, Op: "delete output of", Status: j.status, Err: ErrJobNotEnded}
+	}
+
+	if j.outputDeleted {
+		return nil
+	}
+
+	if err := os.RemoveAll(j.outputDir); err != nil {
+		return err
+	}
+
+	j.outputDeleted = true
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestValidateOutputDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     func(t *testing.T) string
+		wantErr bool
+	}{
+		{
+			name: "Should create missing output directory",
+			dir: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "output")
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should reject relative output directory",
+			dir: func(t *testing.T) string {
+				return "output"
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should reject output directory writable by others",
+			dir: func(t *testing.T) string {
+				dir := t.TempDir()
+
+				if err := os.Chmod(dir, 0777); err != nil {
+					t.Fatal(err)
+				}
+
+				return dir
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should reject output directory on a pseudo-filesystem",
+			dir: func(t *testing.T) string {
+				return "/proc/self"
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOutputDir(tt.dir(t)); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOutputDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateOutputDir(t *testing.T) {
+	root := t.TempDir()
+
+	dir, err := createOutputDir(root, "some-job-id")
+
+	if err != nil {
+		t.Fatalf("createOutputDir() error = %v", err)
+	}
+
+	info, err := os.Stat(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := info.Mode().Perm(); got != 0700 {
+		t.Errorf("createOutputDir() mode = %s, want %s", got, os.FileMode(0700))
+	}
+
+	if _, err := createOutputDir(root, "some-job-id"); err == nil {
+		t.Errorf("createOutputDir() for existing directory error = nil, want error")
+	}
+
+	if _, err := createOutputFile(filepath.Join(dir, stdoutName)); err != nil {
+		t.Errorf("createOutputFile() error = %v", err)
+	}
+
+	if _, err := createOutputFile(filepath.Join(dir, stdoutName)); err == nil {
+		t.Errorf("createOutputFile() for existing file error = nil, want error")
+	}
+}
+
+func TestLimitedWriter_Write(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), stdoutName))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	limit := &outputLimit{max: 10}
+	stdout := &limitedWriter{w: f, limit: limit}
+	stderr := &limitedWriter{w: f, limit: limit}
+
+	for _, w := range []*limitedWriter{stdout, stdout, stderr} {
+		if n, err := w.Write([]byte("123456")); err != nil || n != 6 {
+			t.Errorf("Write() = %d, %v, want 6, nil", n, err)
+		}
+	}
+
+	got, err := os.ReadFile(f.Name())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	marker := "\n[output truncated: job exceeded its output limit of 10 bytes]\n"
+	want := "1234561234" + marker
+
+	if string(got) != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestOutput_wait(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Should succeed when a background process keeps the output open",
+			script: "sleep 5 & echo hi",
+			want:   "hi\n",
+		},
+		{
+			name:    "Should fail when the command fails",
+			script:  "echo hi; exit 1",
+			want:    "hi\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &Job{
+				outputDir:   t.TempDir(),
+				clock:       &testClock{time: UnixEpoch()},
+				stdoutIndex: newOutputIndex(),
+				stderrIndex: newOutputIndex(),
+			}
+
+			out, err := j.openOutput()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command("sh", "-c", tt.script)
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			out.set(cmd)
+
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Kill the background process along with the rest of the command's process group
+			defer syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+
+			start := time.Now()
+			err = out.wait(cmd)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wait() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("wait() took %s, want at most the output wait delay", elapsed)
+			}
+
+			out.close()
+
+			stdout, _, err := j.Output(OutputRange{})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer stdout.Close()
+
+			if got, _ := io.ReadAll(stdout); string(got) != tt.want {
+				t.Errorf("stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeOutput Write output of the job to its segments as if its command wrote it; the job needs a clock, an output
+// directory and indexes.
+func writeOutput(t *testing.T, j *Job, policy RotationPolicy, stdout string, stderr string) {
+	streams := []struct {
+		name  string
+		index *outputIndex
+		data  string
+	}{
+		{name: stdoutName, index: j.stdoutIndex, data: stdout},
+		{name: stderrName, index: j.stderrIndex, data: stderr},
+	}
+
+	for _, stream := range streams {
+		w, err := newSegmentWriter(j.outputDir, stream.name, policy, j.clock, stream.index)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stream.data != "" {
+			if _, err := w.Write([]byte(stream.data)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w.close()
+	}
+}
@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"io"
+	"os"
+	"os/exec"
+)
+
+const (
+	// attachmentBufferSize Number of chunks of terminal output buffered for an attachment before output is dropped.
+	attachmentBufferSize = 256
+)
+
+// WindowSize Size of a terminal in characters.
+type WindowSize struct {
+	Rows uint16
+	Cols uint16
+}
+
+// Attachment Connection to the terminal of a job that receives the terminal's output and can write to its input.
+type Attachment struct {
+	job    *Job
+	output chan []byte
+}
+
+// openPTY Allocate a new pseudo-terminal, returning its master and slave ends.
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var num int
+
+	err = control(master, func(fd int) error {
+		if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return err
+		}
+
+		num, err = unix.IoctlGetInt(fd, unix.TIOCGPTN)
+
+		return err
+	})
+
+	if err != nil {
+		master.Close()
+
+		return nil, nil, err
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", num), os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+
+	if err != nil {
+		master.Close()
+
+		return nil, nil, err
+	}
+
+	return master, slave, nil
+}
+
+// openTerminal Allocate the pseudo-terminal of the job, if the job runs with one, and copy everything written to it to
+// the output.
+func (j *Job) openTerminal(output io.Writer) error {
+	if !j.tty {
+		return nil
+	}
+
+	master, slave, err := openPTY()
+
+	if err != nil {
+		return err
+	}
+
+	if err := setWindowSize(master, j.windowSize); err != nil {
+		master.Close()
+		slave.Close()
+
+		return err
+	}
+
+	j.mu.Lock()
+	j.ptyMaster = master
+	j.ptySlave = slave
+	j.attachments = make(map[*Attachment]struct{})
+	j.mu.Unlock()
+
+	go j.copyTerminal(master, output)
+
+	return nil
+}
+
+// setTerminal Make the pseudo-terminal of the job the controlling terminal of the command.
+func (j *Job) setTerminal(cmd *exec.Cmd) {
+	cmd.Stdin = j.ptySlave
+	cmd.Stdout = j.ptySlave
+	cmd.Stderr = j.ptySlave
+
+	// A new session is needed to have a controlling terminal, which also puts the command in a new process group
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+}
+
+// copyTerminal Copy the output of the terminal to the output and every attachment until the terminal is closed.
+func (j *Job) copyTerminal(master *os.File, output io.Writer) {
+	defer j.detachAll()
+
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := master.Read(buf)
+
+		if n > 0 {
+			if _, err := output.Write(buf[:n]); err != nil {
+				logger.Error("Failed to write terminal output", "id", j.id, "err", err)
+			}
+
+			j.broadcast(buf[:n])
+		}
+
+		if err != nil {
+			// Reading the master fails with EIO once the terminal is closed
+			return
+		}
+	}
+}
+
+// closeTerminal Close the pseudo-terminal of the job once the job's command won't be executed again.
+func (j *Job) closeTerminal() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.ptySlave != nil {
+		j.ptySlave.Close()
+	}
+
+	if j.ptyMaster != nil {
+		j.ptyMaster.Close()
+	}
+}
+
+// broadcast Send a chunk of terminal output to every attachment.
+func (j *Job) broadcast(data []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for attachment := range j.attachments {
+		chunk := make([]byte, len(data))
+		copy(chunk, data)
+
+		select {
+		case attachment.output <- chunk:
+		default:
+			logger.Warn("Dropping terminal output for slow attachment", "id", j.id, "bytes", len(chunk))
+		}
+	}
+}
+
+// detachAll Detach every attachment from the job's terminal.
+func (j *Job) detachAll() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for attachment := range j.attachments {
+		close(attachment.output)
+		delete(j.attachments, attachment)
+	}
+
+	j.attachments = nil
+}
+
+// TTY Returns true if the job's command runs with a pseudo-terminal.
+func (j *Job) TTY() bool {
+	return j.tty
+}
+
+// Attach Attach to the pseudo-terminal of a running job.
+func (j *Job) Attach() (*Attachment, error) {
+	if !j.tty {
+		return nil, ErrNoTerminal
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.ptyMaster == nil || j.attachments == nil {
+		return nil, fmt.Errorf("%w: job terminal is not open", ErrJobNotRunning)
+	}
+
+	attachment := &Attachment{
+		job:    j,
+		output: make(chan []byte, attachmentBufferSize),
+	}
+
+	j.attachments[attachment] = struct{}{}
+
+	return attachment, nil
+}
+
+// Output Returns the output of the terminal; closed when the attachment is detached or the terminal is closed.
+func (a *Attachment) Output() <-chan []byte {
+	return a.output
+}
+
+// Write Write input to the terminal.
+func (a *Attachment) Write(p []byte) (int, error) {
+	return a.job.ptyMaster.Write(p)
+}
+
+// Resize Change the size of the terminal.
+func (a *Attachment) Resize(size WindowSize) error {
+	return setWindowSize(a.job.ptyMaster, size)
+}
+
+// Detach Stop receiving output from the terminal.
+func (a *Attachment) Detach() {
+	a.job.mu.Lock()
+	defer a.job.mu.Unlock()
+
+	if _, ok := a.job.attachments[a]; ok {
+		close(a.output)
+		delete(a.job.attachments, a)
+	}
+}
+
+// setWindowSize Set the size of the terminal; a size of zero leaves the terminal's size unchanged.
+func setWindowSize(terminal *os.File, size WindowSize) error {
+	if size.Rows == 0 || size.Cols == 0 {
+		return nil
+	}
+
+	return control(terminal, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: size.Rows, Col: size.Cols})
+	})
+}
+
+// control Run a function on the file descriptor of the file. Unlike using Fd, this keeps the file in non-blocking mode
+// so that closing the terminal interrupts pending reads.
+func control(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+
+	if err := conn.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+
+	return fnErr
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+// syncBuffer Buffer that can be written to by the terminal while being read by the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestJob_Attach(t *testing.T) {
+	j := &Job{tty: true, windowSize: WindowSize{Rows: 24, Cols: 80}}
+	output := &syncBuffer{}
+
+	if err := j.openTerminal(output); err != nil {
+		t.Skipf("pseudo-terminals are unavailable: %v", err)
+	}
+
+	attachment, err := j.Attach()
+
+	if err != nil {
+		t.Fatalf("Attach() error = %v", err)
+	}
+
+	cmd := exec.Command("sh", "-c", "read line; echo got $line; stty size")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	j.setTerminal(cmd)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	if _, err := attachment.Write([]byte("hello\n")); err != nil {
+		t.Errorf("Write() error = %v", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed waiting for command: %v", err)
+	}
+
+	var received strings.Builder
+
+	for !strings.Contains(received.String(), "24 80") {
+		chunk, ok := <-attachment.Output()
+
+		if !ok {
+			break
+		}
+
+		received.Write(chunk)
+	}
+
+	j.closeTerminal()
+
+	for range attachment.Output() {
+	}
+
+	if got := received.String(); !strings.Contains(got, "got hello") || !strings.Contains(got, "24 80") {
+		t.Errorf("attachment output = %q, want it to contain the command's output", got)
+	}
+
+	if got := output.String(); !strings.Contains(got, "got hello") {
+		t.Errorf("output = %q, want it to contain the command's output", got)
+	}
+}
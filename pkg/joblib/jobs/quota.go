@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+)
+
+// ErrQuotaExceeded Returned when starting a job would exceed the quota of its owner.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota Limits on the jobs of a single owner; a zero value means there is no limit. Active jobs are jobs that haven't
+// ended, including jobs that are queued or waiting to restart.
+type Quota struct {
+	// MaxJobs Maximum number of active jobs.
+	MaxJobs int
+	// MaxCPUPercentage Maximum sum of the CPU percentages of active jobs.
+	MaxCPUPercentage int64
+	// MaxMemoryBytes Maximum sum of the memory limits of active jobs.
+	MaxMemoryBytes uint64
+	// MaxJobCPUPercentage Maximum CPU percentage of a single job.
+	MaxJobCPUPercentage int64
+	// MaxJobMemoryBytes Maximum memory limit of a single job.
+	MaxJobMemoryBytes uint64
+	// MaxOutputBytes Maximum disk space used by the output of every job, including jobs that have ended.
+	MaxOutputBytes int64
+}
+
+// Usage Resources used by the jobs of a single owner.
+type Usage struct {
+	// Jobs Number of active jobs.
+	Jobs int
+	// CPUPercentage Sum of the CPU percentages of active jobs.
+	CPUPercentage int64
+	// MemoryBytes Sum of the memory limits of active jobs.
+	MemoryBytes uint64
+	// OutputBytes Disk space used by the output of every job.
+	OutputBytes int64
+}
+
+// Validate Ensure the quota is valid.
+func (q Quota) Validate() error {
+	if q.MaxJobs < 0 || q.MaxCPUPercentage < 0 || q.MaxJobCPUPercentage < 0 || q.MaxOutputBytes < 0 {
+		return fmt.Errorf("quota limits cannot be negative: %+v", q)
+	}
+
+	return nil
+}
+
+// UsageOf Returns the resources used by the jobs.
+func UsageOf(jobs []*Job) Usage {
+	var usage Usage
+
+	for _, job := range jobs {
+		usage.OutputBytes += job.OutputSize()
+
+		if isTerminal(job.Status()) {
+			continue
+		}
+
+		usage.Jobs++
+		usage.CPUPercentage += int64(job.Limits().CPUPercentage)
+		usage.MemoryBytes += job.Limits().MemoryBytes
+	}
+
+	return usage
+}
+
+// Check Ensure a job with the resource limits can be started without exceeding the quota given the resources already
+// in use. A job without a CPU or memory limit can use any amount of it, so it exceeds any limit on that resource.
+func (q Quota) Check(usage Usage, resources cgroups.Resources) error {
+	cpu := int64(resources.CPUPercentage)
+
+	if q.MaxJobs > 0 && usage.Jobs >= q.MaxJobs {
+		return fmt.Errorf("%w: %d of %d active jobs are in use", ErrQuotaExceeded, usage.Jobs, q.MaxJobs)
+	}
+
+	if (q.MaxJobCPUPercentage > 0 || q.MaxCPUPercentage > 0) && cpu <= 0 {
+		return fmt.Errorf("%w: a CPU limit is required", ErrQuotaExceeded)
+	}
+
+	if (q.MaxJobMemoryBytes > 0 || q.MaxMemoryBytes > 0) && resources.MemoryBytes == 0 {
+		return fmt.Errorf("%w: a memory limit is required", ErrQuotaExceeded)
+	}
+
+	if q.MaxJobCPUPercentage > 0 && cpu > q.MaxJobCPUPercentage {
+		return fmt.Errorf(
+			"%w: CPU percentage %d exceeds the maximum of %d per job", ErrQuotaExceeded, cpu, q.MaxJobCPUPercentage,
+		)
+	}
+
+	if q.MaxJobMemoryBytes > 0 && resources.MemoryBytes > q.MaxJobMemoryBytes {
+		return fmt.Errorf(
+			"%w: memory limit of %d bytes exceeds the maximum of %d bytes per job", ErrQuotaExceeded,
+			resources.MemoryBytes, q.MaxJobMemoryBytes,
+		)
+	}
+
+	if q.MaxCPUPercentage > 0 && usage.CPUPercentage+cpu > q.MaxCPUPercentage {
+		return fmt.Errorf(
+			"%w: CPU percentage %d would exceed the total of %d; %d is in use", ErrQuotaExceeded, cpu,
+			q.MaxCPUPercentage, usage.CPUPercentage,
+		)
+	}
+
+	if q.MaxMemoryBytes > 0 && usage.MemoryBytes+resources.MemoryBytes > q.MaxMemoryBytes {
+		return fmt.Errorf(
+			"%w: memory limit of %d bytes would exceed the total of %d bytes; %d bytes are in use", ErrQuotaExceeded,
+			resources.MemoryBytes, q.MaxMemoryBytes, usage.MemoryBytes,
+		)
+	}
+
+	if q.MaxOutputBytes > 0 && usage.OutputBytes >= q.MaxOutputBytes {
+		return fmt.Errorf(
+			"%w: %d of %d bytes of output storage are in use", ErrQuotaExceeded, usage.OutputBytes, q.MaxOutputBytes,
+		)
+	}
+
+	return nil
+}
+
+// RemainingOutputBytes Returns how much more output can be stored given the output already stored, which is only
+// positive if Check succeeded; zero if there is no limit.
+func (q Quota) RemainingOutputBytes(usage Usage) int64 {
+	if q.MaxOutputBytes <= 0 {
+		return 0
+	}
+
+	return q.MaxOutputBytes - usage.OutputBytes
+}
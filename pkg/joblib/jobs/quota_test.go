@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"testing"
+)
+
+func TestQuota_Check(t *testing.T) {
+	tests := []struct {
+		name      string
+		quota     Quota
+		usage     Usage
+		resources cgroups.Resources
+		wantErr   bool
+	}{
+		{
+			name:      "Should allow job within quota",
+			quota:     Quota{MaxJobs: 2, MaxMemoryBytes: 100, MaxJobMemoryBytes: 50},
+			usage:     Usage{Jobs: 1, MemoryBytes: 50},
+			resources: cgroups.Resources{MemoryBytes: 50},
+		},
+		{
+			name:      "Should allow any job without quota",
+			usage:     Usage{Jobs: 100, OutputBytes: 1 << 40},
+			resources: cgroups.Resources{},
+		},
+		{
+			name:    "Should reject job past max active jobs",
+			quota:   Quota{MaxJobs: 2},
+			usage:   Usage{Jobs: 2},
+			wantErr: true,
+		},
+		{
+			name:      "Should reject job exceeding per job CPU percentage",
+			quota:     Quota{MaxJobCPUPercentage: 100},
+			resources: cgroups.Resources{CPUPercentage: 150},
+			wantErr:   true,
+		},
+		{
+			name:      "Should reject job exceeding total memory",
+			quota:     Quota{MaxMemoryBytes: 100},
+			usage:     Usage{Jobs: 1, MemoryBytes: 60},
+			resources: cgroups.Resources{MemoryBytes: 50},
+			wantErr:   true,
+		},
+		{
+			name:      "Should reject job without memory limit when memory is limited",
+			quota:     Quota{MaxMemoryBytes: 100},
+			resources: cgroups.Resources{CPUPercentage: 50},
+			wantErr:   true,
+		},
+		{
+			name:    "Should reject job once output storage is used up",
+			quota:   Quota{MaxOutputBytes: 1024},
+			usage:   Usage{OutputBytes: 1024},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.Check(tt.usage, tt.resources)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("Check() error = %v, want %v", err, ErrQuotaExceeded)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	// ReapOrphans Kill the processes left behind by jobs that were running when the process managing them exited.
+	ReapOrphans = OrphanPolicy("reap")
+	// ReattachOrphans Keep managing the processes left behind by jobs that were running when the process managing them
+	// exited; the jobs end once all their processes have exited.
+	ReattachOrphans = OrphanPolicy("reattach")
+
+	// ReapedInfo Status info of a job whose processes were killed after the process managing it restarted.
+	ReapedInfo = "processes left running after the server restarted were killed"
+	// ReattachedInfo Status info of a job whose processes were reattached after the process managing it restarted.
+	ReattachedInfo = "reattached after the server restarted; output is no longer captured"
+	// OrphanExitedInfo Status info of a reattached job whose processes have all exited; their exit codes are unknown
+	// since they weren't started by the current process.
+	OrphanExitedInfo = "reattached processes exited with an unknown exit code"
+
+	// orphanPollInterval How often the cgroup of a reattached job is checked for processes.
+	orphanPollInterval = 1 * time.Second
+	// reapPollInterval How often a cgroup whose processes were killed is checked for processes that haven't exited.
+	reapPollInterval = 100 * time.Millisecond
+	// reapAttempts Number of times a cgroup whose processes were killed is checked before giving up on them exiting.
+	reapAttempts = 50
+)
+
+// OrphanPolicy What is done with the processes of jobs that were running when the process managing them exited.
+type OrphanPolicy string
+
+// Validate Ensure the orphan policy is known; the empty policy is the same as ReapOrphans.
+func (p OrphanPolicy) Validate() error {
+	switch p {
+	case "", ReapOrphans, ReattachOrphans:
+		return nil
+	}
+
+	return fmt.Errorf("unknown orphan policy: %s", p)
+}
+
+// Reconciliation Restores persisted jobs and reconciles them with the cgroups left in the worker's cgroup after the
+// process managing the jobs exited, e.g. because it crashed.
+type Reconciliation struct {
+	// CgroupRoot Path that the cgroup v2 hierarchy is mounted at; cgroups.DefaultRoot is used if empty.
+	CgroupRoot string
+	WorkerName string
+	// Policy What is done with the processes of jobs that were running; ReapOrphans is used if empty.
+	Policy OrphanPolicy
+	Clock  clock.Clock
+	// Store Persists changes to the restored jobs; may be nil.
+	Store Store
+	// Scheduler Uses capacity for reattached jobs until they end so that jobs it starts don't exceed its capacity; may
+	// be nil.
+	Scheduler *Scheduler
+}
+
+// Restore Recreate the jobs from their records. Jobs that were running are reattached to or reaped according to the
+// policy if their cgroup still has processes, and are marked as failed otherwise. Cgroups in the worker's cgroup that
+// don't belong to a reattached job are removed after their processes are killed. Reattached jobs use the scheduler's
+// capacity even if it is exceeded, holding back queued jobs until there is room for them.
+func (r Reconciliation) Restore(records []Record) ([]*Job, error) {
+	if err := r.Policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	root := r.CgroupRoot
+
+	if root == "" {
+		root = cgroups.DefaultRoot
+	}
+
+	stale, err := cgroups.JobIDs(root, r.WorkerName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	restored := make([]*Job, 0, len(records))
+
+	for _, record := range records {
+		job := restoreJob(record, r.Clock, r.Store)
+		restored = append(restored, job)
+
+		if isTerminal(job.status) {
+			continue
+		}
+
+		job.recoverIndexes()
+
+		if !slices.Contains(stale, job.id) {
+			job.updateStatusWithInfo(FailedStatus, RestartedInfo)
+		} else if r.reconcile(job, root) {
+			stale = slices.DeleteFunc(stale, func(id string) bool { return id == job.id })
+		}
+	}
+
+	for _, id := range stale {
+		removeStaleCgroup(root, r.WorkerName, id, r.Clock)
+	}
+
+	return restored, nil
+}
+
+// reconcile Reattach to or reap the processes left in the cgroup of a job that was running; returns true if the job was
+// reattached and still uses its cgroup.
+func (r Reconciliation) reconcile(job *Job, root string) bool {
+	cg, err := cgroups.OpenCgroup(root, r.WorkerName, job.id)
+
+	if err != nil {
+		logger.Warn("Failed to open cgroup of restored job", "id", job.id, "err", err)
+		job.updateStatusWithInfo(FailedStatus, RestartedInfo)
+
+		return false
+	}
+
+	if populated, err := cg.Populated(); err != nil || !populated {
+		job.updateStatusWithInfo(FailedStatus, RestartedInfo)
+
+		return false
+	}
+
+	if r.Policy == ReattachOrphans {
+		logger.Info("Reattaching to processes of restored job", "id", job.id)
+
+		// Capacity is used before the job can end so that the scheduler releases it once the processes exit
+		if r.Scheduler != nil {
+			r.Scheduler.adopt(job)
+		}
+
+		job.reattach(cg)
+
+		return true
+	}
+
+	logger.Info("Killing processes of restored job", "id", job.id)
+	killCgroup(cg, r.Clock)
+	job.updateStatusWithInfo(FailedStatus, ReapedInfo)
+
+	return false
+}
+
+// removeStaleCgroup Kill any processes in a cgroup that no longer belongs to a job and remove it.
+func removeStaleCgroup(root string, workerName string, id string, clock clock.Clock) {
+	cg, err := cgroups.OpenCgroup(root, workerName, id)
+
+	if err != nil {
+		logger.Warn("Failed to open stale cgroup", "id", id, "err", err)
+
+		return
+	}
+
+	if populated, err := cg.Populated(); err == nil && populated {
+		logger.Info("Killing processes in stale cgroup", "id", id)
+		killCgroup(cg, clock)
+	}
+
+	logger.Info("Removing stale cgroup", "id", id)
+	cg.Cleanup()
+}
+
+// killCgroup Kill every process in the cgroup and wait for them to exit.
+func killCgroup(cg *cgroups.Cgroup, clock clock.Clock) {
+	if err := cg.Kill(); err != nil {
+		logger.Warn("Failed to kill processes in cgroup", "err", err)
+
+		return
+	}
+
+	waitUnpopulated(cg, clock)
+}
+
+// waitUnpopulated Wait for every process in a cgroup whose processes were killed to exit, since the cgroup can't be
+// removed until they have.
+func waitUnpopulated(cg *cgroups.Cgroup, clock clock.Clock) {
+	for attempt := 0; attempt < reapAttempts; attempt++ {
+		if populated, err := cg.Populated(); err != nil || !populated {
+			return
+		}
+
+		<-clock.After(reapPollInterval)
+	}
+
+	logger.Warn("Processes in cgroup did not exit after being killed")
+}
+
+// reattach Manage the processes left in the job's cgroup by the previous process; the job ends once they have all
+// exited. Their output can no longer be captured, and the job's time limit and restart policy no longer apply.
+func (j *Job) reattach(cg *cgroups.Cgroup) {
+	pids, _ := cg.Procs()
+
+	j.mu.Lock()
+	j.cgroup = cg
+	j.orphaned = true
+
+	// The PID may have been reused by another process, so the job's command is only tracked if it is still in the cgroup
+	if n := len(j.runs); n > 0 && slices.Contains(pids, j.runs[n-1].PID) {
+		if process, err := os.FindProcess(j.runs[n-1].PID); err == nil {
+			j.command.Process = process
+		}
+	}
+
+	j.mu.Unlock()
+
+	j.updateStatusWithInfo(RunningStatus, ReattachedInfo)
+
+	go j.watchOrphans()
+}
+
+// watchOrphans Wait for every process in the cgroup of a reattached job to exit, then end the job.
+func (j *Job) watchOrphans() {
+	for {
+		select {
+		case <-j.clock.After(orphanPollInterval):
+		case <-j.halt:
+		}
+
+		if j.isStopped() {
+			return
+		}
+
+		if populated, err := j.cgroup.Populated(); err == nil && populated {
+			continue
+		}
+
+		break
+	}
+
+	j.cgroup.Cleanup()
+
+	j.mu.Lock()
+
+	if n := len(j.runs); n > 0 && j.runs[n-1].EndedAt.IsZero() {
+		j.runs[n-1].EndedAt = j.clock.Now()
+	}
+
+	j.mu.Unlock()
+
+	j.updateStatusWithInfo(FailedStatus, OrphanExitedInfo)
+}
+
+// isOrphaned Returns true if the job's processes were reattached after the process managing them exited.
+func (j *Job) isOrphaned() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.orphaned
+}
+
+// recoverIndexes Index output that the job's command wrote after its record was last saved.
+func (j *Job) recoverIndexes() {
+	if j.outputDeleted {
+		return
+	}
+
+	for stream, index := range map[string]*outputIndex{stdoutName: j.stdoutIndex, stderrName: j.stderrIndex} {
+		if err := index.recover(j.outputDir, stream); err != nil {
+			logger.Warn("Failed to recover index of restored job output", "id", j.id, "stream", stream, "err", err)
+		}
+	}
+}
+
+// recover Index output written after the end of the indexed output, e.g. output that was written after the index was
+// last persisted. The output is recorded as written when its segment was last modified.
+func (idx *outputIndex) recover(dir string, stream string) error {
+	r, err := openSegments(dir, stream, idx, idx.size)
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	buf := make([]byte, 32*1024)
+
+	// Segments are numbered from one, so the number of indexed segments is the number of the last one
+	idx.mu.RLock()
+	segment := len(idx.segmentStarts)
+	idx.mu.RUnlock()
+
+	for {
+		n, err := r.Read(buf)
+
+		for ; segment < r.index; segment++ {
+			idx.startSegment()
+		}
+
+		if n > 0 {
+			modified := time.Time{}
+
+			if info, err := r.file.Stat(); err == nil {
+				modified = info.ModTime()
+			}
+
+			idx.record(buf[:n], modified)
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
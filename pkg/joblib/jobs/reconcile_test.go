@@ -0,0 +1,274 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconciliation_Restore(t *testing.T) {
+	root := t.TempDir()
+	workerDir := filepath.Join(root, "worker")
+
+	// Cgroups left behind in the worker's cgroup, keyed by job ID, with whether they still have processes
+	cgroupDirs := map[string]bool{
+		"exited":   false,
+		"orphaned": true,
+		"ended":    false,
+		"stale":    true,
+	}
+
+	for id, populated := range cgroupDirs {
+		dir := filepath.Join(workerDir, id)
+
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+
+		events := "populated 0\nfrozen 0\n"
+
+		if populated {
+			events = "populated 1\nfrozen 0\n"
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.events"), []byte(events), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.kill"), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := []Record{
+		{ID: "exited", Status: RunningStatus, OutputDir: t.TempDir()},
+		{ID: "orphaned", Status: RunningStatus, OutputDir: t.TempDir()},
+		{ID: "missing", Status: RunningStatus, OutputDir: t.TempDir()},
+		{ID: "ended", Status: SucceededStatus, OutputDir: t.TempDir()},
+	}
+
+	reconciliation := Reconciliation{
+		CgroupRoot: root,
+		WorkerName: "worker",
+		Policy:     ReapOrphans,
+		Clock:      &testClock{time: UnixEpoch()},
+	}
+
+	restored, err := reconciliation.Restore(records)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		status Status
+		info   string
+	}{
+		"exited":   {status: FailedStatus, info: RestartedInfo},
+		"orphaned": {status: FailedStatus, info: ReapedInfo},
+		"missing":  {status: FailedStatus, info: RestartedInfo},
+		"ended":    {status: SucceededStatus},
+	}
+
+	if len(restored) != len(want) {
+		t.Fatalf("Restore() restored %d jobs, want %d", len(restored), len(want))
+	}
+
+	for _, job := range restored {
+		if w := want[job.ID()]; job.Status() != w.status || job.StatusInfo() != w.info {
+			t.Errorf("Restore() job %s = %s (%s), want %s (%s)", job.ID(), job.Status(), job.StatusInfo(), w.status, w.info)
+		}
+	}
+
+	for _, id := range []string{"orphaned", "stale"} {
+		if kill, err := os.ReadFile(filepath.Join(workerDir, id, "cgroup.kill")); err == nil && string(kill) != "1" {
+			t.Errorf("Restore() did not kill processes of cgroup %s", id)
+		}
+	}
+
+	for _, id := range []string{"exited", "ended"} {
+		if _, err := os.Stat(filepath.Join(workerDir, id)); !os.IsNotExist(err) {
+			t.Errorf("Restore() did not remove cgroup %s: %v", id, err)
+		}
+	}
+}
+
+func TestReconciliation_reconcile(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "worker", "orphaned")
+	events := filepath.Join(dir, "cgroup.events")
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(events, []byte("populated 1\nfrozen 0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler := NewScheduler(SchedulerLimits{MaxRunning: 1})
+	clock := &testClock{time: UnixEpoch()}
+
+	reconciliation := Reconciliation{
+		CgroupRoot: root,
+		WorkerName: "worker",
+		Policy:     ReattachOrphans,
+		Clock:      clock,
+		Scheduler:  scheduler,
+	}
+
+	restored, err := reconciliation.Restore([]Record{{ID: "orphaned", Status: RunningStatus, OutputDir: t.TempDir()}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A job submitted while the reattached job runs is queued behind it
+	queued := &Job{id: "queued", clock: clock, status: ReadyStatus}
+
+	if err := scheduler.Submit(queued); err != nil {
+		t.Fatal(err)
+	}
+
+	if queued.Status() != QueuedStatus {
+		t.Errorf("Submit() status = %s, want %s", queued.Status(), QueuedStatus)
+	}
+
+	// The queued job can't be started without a cgroup, so it is stopped before the reattached job ends
+	queued.updateStatus(StoppedStatus)
+
+	if err := os.WriteFile(events, []byte("populated 0\nfrozen 0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for restored[0].Status() == RunningStatus {
+		time.Sleep(time.Millisecond)
+	}
+
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if _, ok := scheduler.running[restored[0]]; ok {
+		t.Error("scheduler did not release the reattached job")
+	}
+}
+
+// exitClock Clock whose waits end right away; the processes in the cgroup exit once they are waited on after being
+// killed.
+type exitClock struct {
+	testClock
+	mu        sync.Mutex
+	events    string
+	reapWaits int
+}
+
+func (ec *exitClock) After(d time.Duration) <-chan time.Time {
+	if d == reapPollInterval {
+		ec.mu.Lock()
+		ec.reapWaits++
+		ec.mu.Unlock()
+
+		os.WriteFile(ec.events, []byte("populated 0\nfrozen 0\n"), 0600)
+	}
+
+	return ec.testClock.After(d)
+}
+
+func TestJob_StopReattached(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "worker", "orphaned")
+	events := filepath.Join(dir, "cgroup.events")
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(events, []byte("populated 1\nfrozen 0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.kill"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &exitClock{testClock: testClock{time: UnixEpoch()}, events: events}
+
+	reconciliation := Reconciliation{
+		CgroupRoot: root,
+		WorkerName: "worker",
+		Policy:     ReattachOrphans,
+		Clock:      clock,
+	}
+
+	// The job's command isn't tracked since the record has no runs, so only the cgroup has its processes
+	restored, err := reconciliation.Restore([]Record{{ID: "orphaned", Status: RunningStatus, OutputDir: t.TempDir()}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := restored[0].Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if status := restored[0].Status(); status != StoppedStatus {
+		t.Errorf("Stop() status = %s, want %s", status, StoppedStatus)
+	}
+
+	if kill, err := os.ReadFile(filepath.Join(dir, "cgroup.kill")); err == nil && string(kill) != "1" {
+		t.Error("Stop() did not kill the processes in the cgroup")
+	}
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	if clock.reapWaits == 0 {
+		t.Error("Stop() did not wait for the killed processes to exit before removing the cgroup")
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Stop() did not remove the cgroup: %v", err)
+	}
+}
+
+func TestOutputIndex_recover(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{time: UnixEpoch()}
+	index := newOutputIndex()
+
+	w, err := newSegmentWriter(dir, stdoutName, RotationPolicy{MaxSegmentBytes: 8}, clock, index)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("one\ntwo\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The index is persisted before the rest of the output is written
+	snapshot := index.snapshot()
+
+	if _, err := w.Write([]byte("three\nfour\nfive")); err != nil {
+		t.Fatal(err)
+	}
+
+	w.close()
+
+	recovered := restoreIndex(snapshot)
+
+	if err := recovered.recover(dir, stdoutName); err != nil {
+		t.Fatal(err)
+	}
+
+	if recovered.size != index.size || recovered.lines != index.lines || recovered.lastByte != index.lastByte {
+		t.Errorf("recover() size, lines = %d, %d, want %d, %d", recovered.size, recovered.lines, index.size, index.lines)
+	}
+
+	if !reflect.DeepEqual(recovered.segmentStarts, index.segmentStarts) {
+		t.Errorf("recover() segment starts = %v, want %v", recovered.segmentStarts, index.segmentStarts)
+	}
+}
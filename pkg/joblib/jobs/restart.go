@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// NeverRestart Never restart the job's command after it exits.
+	NeverRestart = RestartMode("never")
+	// RestartOnFailure Restart the job's command only when it exits with a non-zero exit code.
+	RestartOnFailure = RestartMode("on-failure")
+	// AlwaysRestart Restart the job's command whenever it exits until the job is stopped.
+	AlwaysRestart = RestartMode("always")
+
+	DefaultInitialBackoff = 1 * time.Second
+	DefaultMaxBackoff     = 5 * time.Minute
+)
+
+// RestartMode When the job's command is restarted after it exits.
+type RestartMode string
+
+// RestartPolicy Describes if and how a job's command is restarted after it exits.
+type RestartPolicy struct {
+	Mode RestartMode
+	// MaxRetries Maximum number of times the command is restarted; zero means there is no maximum.
+	MaxRetries int
+	// InitialBackoff How long to wait before the first restart; doubled for every restart after that.
+	InitialBackoff time.Duration
+	// MaxBackoff Longest amount of time to wait between restarts.
+	MaxBackoff time.Duration
+}
+
+// Run A single execution (attempt) of the job's command.
+type Run struct {
+	Attempt   int
+	StartedAt time.Time
+	EndedAt   time.Time
+	// ExitCode Exit code of the command; -1 if the command was terminated by a signal or never started.
+	ExitCode int
+	// PID Process ID of the command; zero if the command never started.
+	PID int
+	// Stdout Location of the output written to stdout during this run.
+	Stdout Segment
+	// Stderr Location of the output written to stderr during this run.
+	Stderr Segment
+}
+
+// Segment Range of bytes in a job's output.
+type Segment struct {
+	Offset int64
+	Length int64
+}
+
+// Validate Ensure the restart policy is valid.
+func (p RestartPolicy) Validate() error {
+	switch p.Mode {
+	case "", NeverRestart, RestartOnFailure, AlwaysRestart:
+	default:
+		return fmt.Errorf("unknown restart mode: %s", p.Mode)
+	}
+
+	if p.MaxRetries < 0 {
+		return fmt.Errorf("max retries cannot be negative: %d", p.MaxRetries)
+	}
+
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("backoff cannot be negative")
+	}
+
+	return nil
+}
+
+// shouldRestart Returns true if the command should be restarted after the given attempt ended.
+func (p RestartPolicy) shouldRestart(succeeded bool, attempt int) bool {
+	if p.MaxRetries > 0 && attempt > p.MaxRetries {
+		return false
+	}
+
+	switch p.Mode {
+	case RestartOnFailure:
+		return !succeeded
+	case AlwaysRestart:
+		return true
+	}
+
+	return false
+}
+
+// backoff Returns how long to wait before restarting the command after the given attempt; the wait doubles with every
+// attempt up to the maximum backoff.
+func (p RestartPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+
+	if initial == 0 {
+		initial = DefaultInitialBackoff
+	}
+
+	maxBackoff := p.MaxBackoff
+
+	if maxBackoff == 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	backoff := initial
+
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return min(backoff, maxBackoff)
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicy_shouldRestart(t *testing.T) {
+	type args struct {
+		succeeded bool
+		attempt   int
+	}
+	tests := []struct {
+		name   string
+		policy RestartPolicy
+		args   args
+		want   bool
+	}{
+		{
+			name:   "Should never restart",
+			policy: RestartPolicy{Mode: NeverRestart},
+			args:   args{succeeded: false, attempt: 1},
+			want:   false,
+		},
+		{
+			name:   "Should restart failed command",
+			policy: RestartPolicy{Mode: RestartOnFailure},
+			args:   args{succeeded: false, attempt: 1},
+			want:   true,
+		},
+		{
+			name:   "Should not restart succeeded command on failure",
+			policy: RestartPolicy{Mode: RestartOnFailure},
+			args:   args{succeeded: true, attempt: 1},
+			want:   false,
+		},
+		{
+			name:   "Should not restart failed command after max retries",
+			policy: RestartPolicy{Mode: RestartOnFailure, MaxRetries: 2},
+			args:   args{succeeded: false, attempt: 3},
+			want:   false,
+		},
+		{
+			name:   "Should restart failed command before max retries",
+			policy: RestartPolicy{Mode: RestartOnFailure, MaxRetries: 2},
+			args:   args{succeeded: false, attempt: 2},
+			want:   true,
+		},
+		{
+			name:   "Should always restart succeeded command",
+			policy: RestartPolicy{Mode: AlwaysRestart},
+			args:   args{succeeded: true, attempt: 10},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.shouldRestart(tt.args.succeeded, tt.args.attempt); got != tt.want {
+				t.Errorf("shouldRestart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestartPolicy_backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RestartPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{
+			name:    "Should use default initial backoff",
+			policy:  RestartPolicy{},
+			attempt: 1,
+			want:    DefaultInitialBackoff,
+		},
+		{
+			name:    "Should double backoff for each attempt",
+			policy:  RestartPolicy{InitialBackoff: time.Second},
+			attempt: 4,
+			want:    8 * time.Second,
+		},
+		{
+			name:    "Should not exceed max backoff",
+			policy:  RestartPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second},
+			attempt: 4,
+			want:    5 * time.Second,
+		},
+		{
+			name:    "Should not exceed default max backoff",
+			policy:  RestartPolicy{InitialBackoff: time.Second},
+			attempt: 100,
+			want:    DefaultMaxBackoff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt); got != tt.want {
+				t.Errorf("backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package jobs
+
+import (
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RetentionPolicy Describes how long the output of finished jobs is kept and how much disk space it can use.
+type RetentionPolicy struct {
+	// TTL How long the output of a job is kept after the job ends; zero means output is kept until it is evicted.
+	TTL time.Duration
+	// MaxTotalBytes Maximum amount of disk space the output of all jobs can use; when exceeded, the output of the
+	// finished jobs that were least recently used is deleted first. Zero means there is no maximum.
+	MaxTotalBytes int64
+}
+
+// Retention Deletes the output of finished jobs according to a retention policy.
+type Retention struct {
+	mu     sync.Mutex
+	policy RetentionPolicy
+	clock  clock.Clock
+	jobs   map[string]*Job
+}
+
+// NewRetention Create a retention subsystem that applies the policy to the jobs it tracks.
+func NewRetention(policy RetentionPolicy, clock clock.Clock) *Retention {
+	return &Retention{
+		policy: policy,
+		clock:  clock,
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// Track Apply the retention policy to the output of the job.
+func (r *Retention) Track(job *Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID()] = job
+}
+
+// Untrack Stop applying the retention policy to the output of the job.
+func (r *Retention) Untrack(job *Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.jobs, job.ID())
+}
+
+// Run Apply the retention policy every interval; blocks forever.
+func (r *Retention) Run(interval time.Duration) {
+	for {
+		<-r.clock.After(interval)
+
+		r.Sweep()
+	}
+}
+
+// Sweep Delete the output of jobs that ended longer ago than the TTL, then delete the output of the least recently used
+// finished jobs until the total disk usage is within the maximum.
+func (r *Retention) Sweep() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+
+	var finished []*Job
+	var total int64
+
+	for _, job := range r.jobs {
+		if job.OutputDeleted() {
+			continue
+		}
+
+		ended, isFinished := job.Ended()
+
+		if isFinished && r.policy.TTL > 0 && now.Sub(ended) > r.policy.TTL {
+			logger.Info("Deleting output of expired job", "id", job.ID(), "ended", ended)
+			r.deleteOutput(job)
+
+			continue
+		}
+
+		total += job.OutputSize()
+
+		if isFinished {
+			finished = append(finished, job)
+		}
+	}
+
+	if r.policy.MaxTotalBytes <= 0 || total <= r.policy.MaxTotalBytes {
+		return
+	}
+
+	slices.SortFunc(finished, func(a *Job, b *Job) int {
+		return a.LastAccessed().Compare(b.LastAccessed())
+	})
+
+	for _, job := range finished {
+		if total <= r.policy.MaxTotalBytes {
+			break
+		}
+
+		size := job.OutputSize()
+
+		logger.Info("Evicting output of job to free disk space", "id", job.ID(), "bytes", size)
+		r.deleteOutput(job)
+
+		total -= size
+	}
+
+	if total > r.policy.MaxTotalBytes {
+		logger.Warn("Output of running jobs exceeds the maximum disk usage", "bytes", total, "max", r.policy.MaxTotalBytes)
+	}
+}
+
+// deleteOutput Delete the output of the job, logging any failure.
+func (r *Retention) deleteOutput(job *Job) {
+	if err := job.DeleteOutput(); err != nil {
+		logger.Error("Failed to delete job output", "id", job.ID(), "err", err)
+	}
+}
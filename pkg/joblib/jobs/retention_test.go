@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestJob Create a job with output of the given size that has the status and ended at the given time.
+func newTestJob(t *testing.T, id string, status Status, ended time.Time, outputSize int) *Job {
+	dir := filepath.Join(t.TempDir(), id)
+
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	j := &Job{
+		id:        id,
+		status:    status,
+		ended:     ended,
+		created:   UnixEpoch(),
+		clock:     &testClock{time: UnixEpoch()},
+		outputDir: dir,
+	}
+
+	if err := os.WriteFile(segmentName(dir, stdoutName, 1), []byte(strings.Repeat("x", outputSize)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return j
+}
+
+func TestRetention_Sweep(t *testing.T) {
+	now := UnixEpoch().Add(48 * time.Hour)
+
+	tests := []struct {
+		name        string
+		policy      RetentionPolicy
+		jobs        func(t *testing.T) []*Job
+		wantDeleted []string
+	}{
+		{
+			name:   "Should delete output of jobs that ended before the TTL",
+			policy: RetentionPolicy{TTL: 24 * time.Hour},
+			jobs: func(t *testing.T) []*Job {
+				return []*Job{
+					newTestJob(t, "expired", SucceededStatus, now.Add(-25*time.Hour), 10),
+					newTestJob(t, "recent", FailedStatus, now.Add(-time.Hour), 10),
+					newTestJob(t, "running", RunningStatus, time.Time{}, 10),
+				}
+			},
+			wantDeleted: []string{"expired"},
+		},
+		{
+			name:   "Should evict least recently used output when over the maximum",
+			policy: RetentionPolicy{MaxTotalBytes: 25},
+			jobs: func(t *testing.T) []*Job {
+				return []*Job{
+					newTestJob(t, "oldest", SucceededStatus, now.Add(-3*time.Hour), 10),
+					newTestJob(t, "older", StoppedStatus, now.Add(-2*time.Hour), 10),
+					newTestJob(t, "newest", FailedStatus, now.Add(-time.Hour), 10),
+				}
+			},
+			wantDeleted: []string{"oldest"},
+		},
+		{
+			name:   "Should not evict output of running jobs",
+			policy: RetentionPolicy{MaxTotalBytes: 5},
+			jobs: func(t *testing.T) []*Job {
+				return []*Job{
+					newTestJob(t, "running", RunningStatus, time.Time{}, 10),
+					newTestJob(t, "finished", SucceededStatus, now.Add(-time.Hour), 10),
+				}
+			},
+			wantDeleted: []string{"finished"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRetention(tt.policy, &testClock{time: now})
+			testJobs := tt.jobs(t)
+
+			for _, j := range testJobs {
+				r.Track(j)
+			}
+
+			r.Sweep()
+
+			for _, j := range testJobs {
+				wantDeleted := false
+
+				for _, id := range tt.wantDeleted {
+					wantDeleted = wantDeleted || id == j.ID()
+				}
+
+				if got := j.OutputDeleted(); got != wantDeleted {
+					t.Errorf("job %s OutputDeleted() = %v, want %v", j.ID(), got, wantDeleted)
+				}
+
+				if _, err := os.Stat(j.OutputDir()); os.IsNotExist(err) != wantDeleted {
+					t.Errorf("job %s output directory exists = %v, want %v", j.ID(), err == nil, !wantDeleted)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package jobs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// NoCompression Closed segments of output are left uncompressed.
+	NoCompression = Compression("")
+	// GzipCompression Closed segments of output are compressed with gzip.
+	GzipCompression = Compression("gzip")
+	// ZstdCompression Closed segments of output are compressed with zstd.
+	ZstdCompression = Compression("zstd")
+)
+
+var (
+	// compressedExtensions Extensions added to the names of segments compressed with each type of compression.
+	compressedExtensions = map[Compression]string{
+		GzipCompression: ".gz",
+		ZstdCompression: ".zst",
+	}
+)
+
+// Compression How closed segments of a job's output are compressed.
+type Compression string
+
+// RotationPolicy Describes when the output of a job is rotated into a new segment and how closed segments are
+// compressed. The output of a job is never rotated by default.
+type RotationPolicy struct {
+	// MaxSegmentBytes Size in bytes at which a segment is closed and output continues in a new one; zero means
+	// segments aren't rotated by size.
+	MaxSegmentBytes int64
+	// MaxSegmentAge How long output is written to a segment before it is closed and output continues in a new one;
+	// zero means segments aren't rotated by age. Segments are only rotated when output is written.
+	MaxSegmentAge time.Duration
+	// Compression How segments are compressed once they are closed, including the last segment once the job ends.
+	Compression Compression
+}
+
+// Validate Ensure the rotation policy is valid.
+func (p RotationPolicy) Validate() error {
+	if p.MaxSegmentBytes < 0 {
+		return fmt.Errorf("max segment bytes cannot be negative: %d", p.MaxSegmentBytes)
+	}
+
+	if p.MaxSegmentAge < 0 {
+		return fmt.Errorf("max segment age cannot be negative: %s", p.MaxSegmentAge)
+	}
+
+	if _, ok := compressedExtensions[p.Compression]; !ok && p.Compression != NoCompression {
+		return fmt.Errorf("unknown compression: %s", p.Compression)
+	}
+
+	return nil
+}
+
+// rotates Returns true if output is rotated into new segments.
+func (p RotationPolicy) rotates() bool {
+	return p.MaxSegmentBytes > 0 || p.MaxSegmentAge > 0
+}
+
+// segmentWriter Writes a stream of output (e.g. stdout) to a series of segment files, closing the current segment and
+// starting a new one whenever the rotation policy says so.
+type segmentWriter struct {
+	mu          sync.Mutex
+	dir         string
+	stream      string
+	policy      RotationPolicy
+	clock       clock.Clock
+	outputIndex *outputIndex
+	index       int
+	file        *os.File
+	opened      time.Time
+	size        int64
+	compressing sync.WaitGroup
+}
+
+// segmentReader Reads a stream of output from the start of its first segment through the end of its last segment,
+// decompressing segments as needed.
+type segmentReader struct {
+	dir    string
+	stream string
+	index  int
+	file   *os.File
+	reader io.Reader
+	closer func()
+}
+
+// segmentName Returns the name of the uncompressed segment of a stream of output.
+func segmentName(dir string, stream string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.%d", stream, index))
+}
+
+// newSegmentWriter Create the first segment of a stream of output, recording everything written to it in the index.
+func newSegmentWriter(dir string, stream string, policy RotationPolicy, clock clock.Clock, index *outputIndex) (*segmentWriter, error) {
+	w := &segmentWriter{
+		dir:         dir,
+		stream:      stream,
+		policy:      policy,
+		clock:       clock,
+		outputIndex: index,
+	}
+
+	if err := w.openSegment(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// openSegment Create the next segment and write output to it.
+func (w *segmentWriter) openSegment() error {
+	file, err := createOutputFile(segmentName(w.dir, w.stream, w.index+1))
+
+	if err != nil {
+		return err
+	}
+
+	w.index++
+	w.file = file
+	w.opened = w.clock.Now()
+	w.size = 0
+	w.outputIndex.startSegment()
+
+	return nil
+}
+
+// closeSegment Close the current segment and compress it in the background if the policy says so.
+func (w *segmentWriter) closeSegment() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	if w.policy.Compression != NoCompression {
+		w.compressing.Add(1)
+
+		go func(name string) {
+			defer w.compressing.Done()
+
+			if err := compressSegment(name, w.policy.Compression); err != nil {
+				logger.Error("Failed to compress output segment", "path", name, "err", err)
+			}
+		}(w.file.Name())
+	}
+
+	return nil
+}
+
+// rotate Close the current segment and continue writing output in a new one.
+func (w *segmentWriter) rotate() error {
+	logger.Debug("Rotating output segment", "path", w.file.Name(), "bytes", w.size)
+
+	if err := w.closeSegment(); err != nil {
+		return err
+	}
+
+	return w.openSegment()
+}
+
+// Write Write output to the current segment, rotating segments as needed so that no segment is larger than the
+// maximum segment size.
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.clock.Now()
+
+	if w.size > 0 && w.policy.MaxSegmentAge > 0 && now.Sub(w.opened) >= w.policy.MaxSegmentAge {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	written := 0
+
+	for written < len(p) {
+		chunk := p[written:]
+
+		if w.policy.MaxSegmentBytes > 0 {
+			if w.size >= w.policy.MaxSegmentBytes {
+				if err := w.rotate(); err != nil {
+					return written, err
+				}
+			}
+
+			chunk = chunk[:min(int64(len(chunk)), w.policy.MaxSegmentBytes-w.size)]
+		}
+
+		n, err := w.file.Write(chunk)
+		w.size += int64(n)
+		written += n
+
+		w.outputIndex.record(chunk[:n], now)
+
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// offset Returns the number of bytes written to the stream across every segment.
+func (w *segmentWriter) offset() int64 {
+	w.outputIndex.mu.RLock()
+	defer w.outputIndex.mu.RUnlock()
+
+	return w.outputIndex.size
+}
+
+// close Close the last segment and wait for every closed segment to be compressed.
+func (w *segmentWriter) close() {
+	w.mu.Lock()
+
+	if err := w.closeSegment(); err != nil {
+		logger.Error("Failed to close output segment", "path", w.file.Name(), "err", err)
+	}
+
+	w.mu.Unlock()
+
+	w.compressing.Wait()
+}
+
+// compressSegment Replace an uncompressed segment with a compressed one. The compressed segment is put in place before
+// the uncompressed one is removed so that readers can always find one of them.
+func compressSegment(name string, compression Compression) error {
+	src, err := os.Open(name)
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	compressed := name + compressedExtensions[compression]
+	tmp := compressed + ".tmp"
+
+	dst, err := createOutputFile(tmp)
+
+	if err != nil {
+		return err
+	}
+
+	if err := compress(dst, src, compression); err != nil {
+		dst.Close()
+
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	if err := dst.Close(); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	if err := os.Rename(tmp, compressed); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	return os.Remove(name)
+}
+
+// compress Copy everything from src to dst using the given compression.
+func compress(dst io.Writer, src io.Reader, compression Compression) error {
+	var w io.WriteCloser
+
+	switch compression {
+	case GzipCompression:
+		w = gzip.NewWriter(dst)
+	case ZstdCompression:
+		enc, err := zstd.NewWriter(dst)
+
+		if err != nil {
+			return err
+		}
+
+		w = enc
+	default:
+		return fmt.Errorf("unknown compression: %s", compression)
+	}
+
+	if _, err := io.Copy(w, src); err != nil {
+		w.Close()
+
+		return err
+	}
+
+	return w.Close()
+}
+
+// openSegments Open a stream of output for reading from the given offset, using the index to find the segment that
+// contains it.
+func openSegments(dir string, stream string, index *outputIndex, offset int64) (*segmentReader, error) {
+	r := &segmentReader{
+		dir:    dir,
+		stream: stream,
+	}
+
+	segment, start := index.segment(offset)
+
+	if err := r.openSegment(segment); err != nil {
+		return nil, err
+	}
+
+	if err := r.skip(offset - start); err != nil {
+		r.Close()
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// skip Skip bytes at the start of the current segment; uncompressed segments are seeked while compressed segments
+// have to be decompressed up to the offset.
+func (r *segmentReader) skip(n int64) error {
+	if n == 0 {
+		return nil
+	}
+
+	if r.reader == io.Reader(r.file) {
+		_, err := r.file.Seek(n, io.SeekStart)
+
+		return err
+	}
+
+	if _, err := io.CopyN(io.Discard, r.reader, n); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// openSegment Open the segment with the given index, whether or not it has been compressed, and continue reading from
+// it.
+func (r *segmentReader) openSegment(index int) error {
+	name := segmentName(r.dir, r.stream, index)
+	file, err := os.Open(name)
+
+	if err == nil {
+		r.setSegment(index, file, file, nil)
+
+		return nil
+	}
+
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	// The segment has been compressed
+	for compression, ext := range compressedExtensions {
+		file, err := os.Open(name + ext)
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		switch compression {
+		case GzipCompression:
+			reader, err := gzip.NewReader(file)
+
+			if err != nil {
+				file.Close()
+
+				return err
+			}
+
+			r.setSegment(index, file, reader, func() { reader.Close() })
+		case ZstdCompression:
+			reader, err := zstd.NewReader(file)
+
+			if err != nil {
+				file.Close()
+
+				return err
+			}
+
+			r.setSegment(index, file, reader, reader.Close)
+		}
+
+		return nil
+	}
+
+	return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+// setSegment Continue reading from the given segment, closing the previous one.
+func (r *segmentReader) setSegment(index int, file *os.File, reader io.Reader, closer func()) {
+	r.Close()
+
+	r.index = index
+	r.file = file
+	r.reader = reader
+	r.closer = closer
+}
+
+// hasNext Returns true if a segment after the current one exists, meaning that the current segment has been closed.
+func (r *segmentReader) hasNext() bool {
+	name := segmentName(r.dir, r.stream, r.index+1)
+
+	for _, ext := range []string{"", compressedExtensions[GzipCompression], compressedExtensions[ZstdCompression]} {
+		if _, err := os.Stat(name + ext); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *segmentReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.reader.Read(p)
+
+		if n > 0 || err != io.EOF {
+			if err == io.EOF {
+				err = nil
+			}
+
+			return n, err
+		}
+
+		if !r.hasNext() {
+			return 0, io.EOF
+		}
+
+		// The current segment may have been written to after it was read and before the next segment was created
+		if n, err := r.reader.Read(p); n > 0 || err != io.EOF {
+			if err == io.EOF {
+				err = nil
+			}
+
+			return n, err
+		}
+
+		if err := r.openSegment(r.index + 1); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Close Close the segment that is currently being read.
+func (r *segmentReader) Close() error {
+	if r.closer != nil {
+		r.closer()
+	}
+
+	if r.file != nil {
+		return r.file.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSegmentWriter_Write(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       RotationPolicy
+		writes       []string
+		wantSegments []string
+	}{
+		{
+			name:         "Should write to a single segment without rotation",
+			policy:       RotationPolicy{},
+			writes:       []string{"hello ", "world"},
+			wantSegments: []string{"stdout.1"},
+		},
+		{
+			name:         "Should split output into segments by size",
+			policy:       RotationPolicy{MaxSegmentBytes: 4},
+			writes:       []string{"hello ", "world"},
+			wantSegments: []string{"stdout.1", "stdout.2", "stdout.3"},
+		},
+		{
+			name:         "Should compress closed segments with gzip",
+			policy:       RotationPolicy{MaxSegmentBytes: 6, Compression: GzipCompression},
+			writes:       []string{"hello ", "world"},
+			wantSegments: []string{"stdout.1.gz", "stdout.2.gz"},
+		},
+		{
+			name:         "Should compress closed segments with zstd",
+			policy:       RotationPolicy{MaxSegmentBytes: 6, Compression: ZstdCompression},
+			writes:       []string{"hello ", "world"},
+			wantSegments: []string{"stdout.1.zst", "stdout.2.zst"},
+		},
+		{
+			name:         "Should rotate segments by age",
+			policy:       RotationPolicy{MaxSegmentAge: time.Nanosecond},
+			writes:       []string{"hello ", "world"},
+			wantSegments: []string{"stdout.1", "stdout.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := &testClock{time: UnixEpoch()}
+			index := newOutputIndex()
+
+			w, err := newSegmentWriter(dir, stdoutName, tt.policy, clock, index)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, data := range tt.writes {
+				if _, err := w.Write([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+
+				clock.time = clock.time.Add(time.Second)
+			}
+
+			want := strings.Join(tt.writes, "")
+
+			if got := w.offset(); got != int64(len(want)) {
+				t.Errorf("offset() = %d, want %d", got, len(want))
+			}
+
+			w.close()
+
+			entries, err := os.ReadDir(dir)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var segments []string
+
+			for _, entry := range entries {
+				segments = append(segments, entry.Name())
+			}
+
+			if strings.Join(segments, ",") != strings.Join(tt.wantSegments, ",") {
+				t.Errorf("segments = %v, want %v", segments, tt.wantSegments)
+			}
+
+			r, err := openSegments(dir, stdoutName, index, 0)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer r.Close()
+
+			got, err := io.ReadAll(r)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != want {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRotationPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RotationPolicy
+		wantErr bool
+	}{
+		{
+			name:    "Should accept default policy",
+			policy:  RotationPolicy{},
+			wantErr: false,
+		},
+		{
+			name:    "Should accept policy with rotation and compression",
+			policy:  RotationPolicy{MaxSegmentBytes: 1024, MaxSegmentAge: time.Hour, Compression: ZstdCompression},
+			wantErr: false,
+		},
+		{
+			name:    "Should reject negative segment size",
+			policy:  RotationPolicy{MaxSegmentBytes: -1},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject unknown compression",
+			policy:  RotationPolicy{Compression: "lz4"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
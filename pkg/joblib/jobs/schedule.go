@@ -0,0 +1,267 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// AllowConcurrent Start a job on every tick even if jobs started by earlier ticks are still active.
+	AllowConcurrent = ConcurrencyPolicy("allow")
+	// ForbidConcurrent Skip a tick if a job started by an earlier tick is still active.
+	ForbidConcurrent = ConcurrencyPolicy("forbid")
+	// ReplaceConcurrent Stop the active jobs started by earlier ticks before starting a job.
+	ReplaceConcurrent = ConcurrencyPolicy("replace")
+
+	// maxScheduleHistory Number of jobs started by a schedule whose IDs are remembered.
+	maxScheduleHistory = 100
+)
+
+// ConcurrencyPolicy What a schedule does when it ticks while jobs it started earlier are still active, i.e. haven't
+// ended.
+type ConcurrencyPolicy string
+
+// Validate Ensure the concurrency policy is known; the empty policy is the same as AllowConcurrent.
+func (p ConcurrencyPolicy) Validate() error {
+	switch p {
+	case "", AllowConcurrent, ForbidConcurrent, ReplaceConcurrent:
+		return nil
+	}
+
+	return fmt.Errorf("unknown concurrency policy: %s", p)
+}
+
+// StartJob Creates a job and starts it, or submits it to a scheduler.
+type StartJob func() (*Job, error)
+
+// Schedule Starts a new job every time its cron expression matches the current time in its location. Schedules are only
+// kept in memory, while the jobs they start are persisted like any other job.
+type Schedule struct {
+	mu       sync.Mutex
+	id       string
+	name     string
+	owner    string
+	cron     CronExpression
+	location *time.Location
+	policy   ConcurrencyPolicy
+	clock    clock.Clock
+	start    StartJob
+	created  time.Time
+	lastRun  time.Time
+	jobIDs   []string
+	active   []*Job
+	skipped  int
+	lastErr  error
+	halt     chan struct{}
+	haltOnce sync.Once
+}
+
+// NewSchedule Create a schedule that starts jobs with the function whenever the cron expression matches the time in the
+// location, which is UTC if nil; no job is started until the schedule is run.
+func NewSchedule(
+	name string, owner string, cron CronExpression, location *time.Location, policy ConcurrencyPolicy,
+	clock clock.Clock, start StartJob,
+) (*Schedule, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if policy == "" {
+		policy = AllowConcurrent
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &Schedule{
+		id:       uuid.NewString(),
+		name:     name,
+		owner:    owner,
+		cron:     cron,
+		location: location,
+		policy:   policy,
+		clock:    clock,
+		start:    start,
+		created:  clock.Now(),
+		halt:     make(chan struct{}),
+	}, nil
+}
+
+// ID Returns the schedule's ID.
+func (s *Schedule) ID() string {
+	return s.id
+}
+
+// Name Returns the schedule's name; may be empty.
+func (s *Schedule) Name() string {
+	return s.name
+}
+
+// Owner Returns the identity of the client that created the schedule; empty if it is unknown.
+func (s *Schedule) Owner() string {
+	return s.owner
+}
+
+// Cron Returns the schedule's cron expression.
+func (s *Schedule) Cron() CronExpression {
+	return s.cron
+}
+
+// Location Returns the location that the schedule's cron expression is matched in.
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// Policy Returns what the schedule does when jobs it started earlier are still active.
+func (s *Schedule) Policy() ConcurrencyPolicy {
+	return s.policy
+}
+
+// Created Returns when the schedule was created.
+func (s *Schedule) Created() time.Time {
+	return s.created
+}
+
+// NextRun Returns the next time the schedule ticks; the zero time if it never will.
+func (s *Schedule) NextRun() time.Time {
+	if s.isStopped() {
+		return time.Time{}
+	}
+
+	return s.cron.Next(s.clock.Now(), s.location)
+}
+
+// LastRun Returns when the schedule last ticked; the zero time if it hasn't.
+func (s *Schedule) LastRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastRun
+}
+
+// JobIDs Returns the IDs of the most recent jobs started by the schedule, oldest first.
+func (s *Schedule) JobIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.jobIDs)
+}
+
+// Skipped Returns the number of ticks that were skipped because of the concurrency policy.
+func (s *Schedule) Skipped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.skipped
+}
+
+// LastError Returns the error from the last time the schedule failed to start a job; nil if it never failed.
+func (s *Schedule) LastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastErr
+}
+
+// Run Tick every time the cron expression matches the current time until the schedule is stopped; blocks until then.
+func (s *Schedule) Run() {
+	logger.Info("Running schedule", "id", s.id, "cron", s.cron, "location", s.location)
+
+	for {
+		next := s.NextRun()
+
+		if next.IsZero() {
+			logger.Warn("Schedule will never tick again", "id", s.id, "cron", s.cron)
+
+			return
+		}
+
+		select {
+		case <-s.clock.After(next.Sub(s.clock.Now())):
+		case <-s.halt:
+			return
+		}
+
+		if s.isStopped() {
+			return
+		}
+
+		s.Tick()
+	}
+}
+
+// Stop Stop ticking; jobs started by the schedule keep running.
+func (s *Schedule) Stop() {
+	logger.Info("Stopping schedule", "id", s.id)
+
+	s.haltOnce.Do(func() {
+		close(s.halt)
+	})
+}
+
+// Tick Start a job according to the concurrency policy.
+func (s *Schedule) Tick() {
+	s.mu.Lock()
+
+	s.lastRun = s.clock.Now()
+	s.active = slices.DeleteFunc(s.active, func(job *Job) bool { return isTerminal(job.Status()) })
+	active := slices.Clone(s.active)
+
+	if len(active) > 0 && s.policy == ForbidConcurrent {
+		s.skipped++
+		s.mu.Unlock()
+
+		logger.Info("Skipping schedule tick since a job it started is still active", "id", s.id)
+
+		return
+	}
+
+	s.mu.Unlock()
+
+	if s.policy == ReplaceConcurrent {
+		for _, job := range active {
+			logger.Info("Replacing job started by schedule", "id", s.id, "job", job.ID())
+
+			if err := job.Stop(); err != nil && !errors.Is(err, ErrJobEnded) {
+				logger.Warn("Failed to stop job replaced by schedule", "id", s.id, "job", job.ID(), "err", err)
+			}
+		}
+	}
+
+	job, err := s.start()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		logger.Error("Failed to start job of schedule", "id", s.id, "err", err)
+		s.lastErr = err
+
+		return
+	}
+
+	logger.Info("Started job of schedule", "id", s.id, "job", job.ID())
+
+	s.active = append(s.active, job)
+	s.jobIDs = append(s.jobIDs, job.ID())
+
+	if len(s.jobIDs) > maxScheduleHistory {
+		s.jobIDs = s.jobIDs[len(s.jobIDs)-maxScheduleHistory:]
+	}
+}
+
+// isStopped Returns true if the schedule was stopped.
+func (s *Schedule) isStopped() bool {
+	select {
+	case <-s.halt:
+		return true
+	default:
+		return false
+	}
+}
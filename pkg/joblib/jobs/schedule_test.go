@@ -0,0 +1,309 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "Should parse wildcards", text: "* * * * *"},
+		{name: "Should parse lists, ranges and steps", text: "0,30 9-17/2 */5 1-6 mon-fri"},
+		{name: "Should parse macros", text: "@daily"},
+		{name: "Should not parse too few fields", text: "* * * *", wantErr: true},
+		{name: "Should not parse values out of range", text: "60 * * * *", wantErr: true},
+		{name: "Should not parse reversed ranges", text: "* 17-9 * * *", wantErr: true},
+		{name: "Should not parse invalid steps", text: "*/0 * * * *", wantErr: true},
+		{name: "Should not parse unknown macros", text: "@sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.text); (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronExpression_Next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skipf("time zone database is unavailable: %v", err)
+	}
+
+	// A Wednesday
+	after := time.Date(2024, time.May, 15, 10, 7, 30, 0, time.UTC)
+
+	// Clocks in New York are set back from 2:00 EDT to 1:00 EST
+	fallBack := time.Date(2024, time.November, 3, 5, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		text     string
+		location *time.Location
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "Should match the next minute",
+			text:     "* * * * *",
+			location: time.UTC,
+			want:     time.Date(2024, time.May, 15, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			name:     "Should match the next step",
+			text:     "*/15 * * * *",
+			location: time.UTC,
+			want:     time.Date(2024, time.May, 15, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "Should match the next day",
+			text:     "0 3 * * *",
+			location: time.UTC,
+			want:     time.Date(2024, time.May, 16, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Should match the day of week",
+			text:     "30 9 * * sun",
+			location: time.UTC,
+			want:     time.Date(2024, time.May, 19, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "Should match either day when both are restricted",
+			text:     "0 0 1 * 5",
+			location: time.UTC,
+			want:     time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Should match the next year",
+			text:     "0 0 1 1 *",
+			location: time.UTC,
+			want:     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Should match in the location",
+			text:     "0 3 * * *",
+			location: newYork,
+			want:     time.Date(2024, time.May, 16, 3, 0, 0, 0, newYork),
+		},
+		{
+			name:     "Should not match a day that doesn't exist",
+			text:     "0 0 30 2 *",
+			location: time.UTC,
+		},
+		{
+			name:     "Should not match a time again when clocks are set back",
+			text:     "30 1 * * *",
+			location: newYork,
+			after:    fallBack,
+			want:     time.Date(2024, time.November, 4, 1, 30, 0, 0, newYork),
+		},
+		{
+			name:     "Should match every hour when clocks are set back",
+			text:     "30 * * * *",
+			location: newYork,
+			after:    fallBack,
+			want:     fallBack.Add(time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseCron(tt.text)
+
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+
+			from := after
+
+			if !tt.after.IsZero() {
+				from = tt.after
+			}
+
+			if got := expr.Next(from, tt.location); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Tick(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      ConcurrencyPolicy
+		wantJobs    int
+		wantSkipped int
+		wantStopped bool
+	}{
+		{name: "Should start concurrent jobs", policy: AllowConcurrent, wantJobs: 2},
+		{name: "Should skip ticks while a job is active", policy: ForbidConcurrent, wantJobs: 1, wantSkipped: 1},
+		{name: "Should stop active jobs before starting a job", policy: ReplaceConcurrent, wantJobs: 2, wantStopped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{time: UnixEpoch()}
+			root := t.TempDir()
+
+			var started []*Job
+
+			start := func() (*Job, error) {
+				job := newRunningJob(t, root, fmt.Sprintf("job-%d", len(started)), clock)
+				started = append(started, job)
+
+				return job, nil
+			}
+
+			expr, _ := ParseCron("@hourly")
+			s, err := NewSchedule("some-schedule", "", expr, nil, tt.policy, clock, start)
+
+			if err != nil {
+				t.Fatalf("NewSchedule() error = %v", err)
+			}
+
+			s.Tick()
+			s.Tick()
+
+			var want []string
+
+			for _, job := range started {
+				want = append(want, job.ID())
+			}
+
+			if got := s.JobIDs(); len(got) != tt.wantJobs || !reflect.DeepEqual(got, want) {
+				t.Errorf("JobIDs() = %v, want %d jobs", got, tt.wantJobs)
+			}
+
+			if got := s.Skipped(); got != tt.wantSkipped {
+				t.Errorf("Skipped() = %d, want %d", got, tt.wantSkipped)
+			}
+
+			if stopped := started[0].Status() == StoppedStatus; stopped != tt.wantStopped {
+				t.Errorf("first job stopped = %v, want %v", stopped, tt.wantStopped)
+			}
+		})
+	}
+}
+
+func TestSchedule_TickError(t *testing.T) {
+	clock := &testClock{time: UnixEpoch()}
+	wantErr := errors.New("some error")
+
+	expr, _ := ParseCron("@hourly")
+	s, _ := NewSchedule("", "", expr, nil, ForbidConcurrent, clock, func() (*Job, error) { return nil, wantErr })
+
+	s.Tick()
+
+	if err := s.LastError(); !errors.Is(err, wantErr) {
+		t.Errorf("LastError() = %v, want %v", err, wantErr)
+	}
+
+	if got := s.JobIDs(); len(got) != 0 {
+		t.Errorf("JobIDs() = %v, want none", got)
+	}
+}
+
+// tickClock Clock whose time advances by the duration waited for, so that a running schedule ticks right away; waits
+// never end once it is blocked.
+type tickClock struct {
+	mu      sync.Mutex
+	time    time.Time
+	blocked bool
+}
+
+func (tc *tickClock) Now() time.Time {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	return tc.time
+}
+
+func (tc *tickClock) After(d time.Duration) <-chan time.Time {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	c := make(chan time.Time, 1)
+
+	if !tc.blocked {
+		tc.time = tc.time.Add(d)
+		c <- tc.time
+	}
+
+	return c
+}
+
+func TestSchedule_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		blocked     bool
+		wantJobs    int
+		wantLastRun time.Time
+	}{
+		{
+			name:        "Should tick every time the expression matches until stopped",
+			wantJobs:    2,
+			wantLastRun: UnixEpoch().Add(2 * time.Hour),
+		},
+		{
+			name:    "Should stop while waiting for the next tick",
+			blocked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &tickClock{time: UnixEpoch(), blocked: tt.blocked}
+
+			var s *Schedule
+			var started int
+
+			// The schedule is stopped by the second tick so that it ticks exactly twice
+			start := func() (*Job, error) {
+				if started++; started == 2 {
+					s.Stop()
+				}
+
+				return &Job{id: fmt.Sprintf("job-%d", started), status: SucceededStatus}, nil
+			}
+
+			expr, _ := ParseCron("@hourly")
+			s, _ = NewSchedule("", "", expr, nil, AllowConcurrent, clock, start)
+
+			done := make(chan struct{})
+
+			go func() {
+				s.Run()
+				close(done)
+			}()
+
+			if tt.blocked {
+				s.Stop()
+			}
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return after the schedule was stopped")
+			}
+
+			if got := s.JobIDs(); len(got) != tt.wantJobs {
+				t.Errorf("JobIDs() = %v, want %d jobs", got, tt.wantJobs)
+			}
+
+			if got := s.LastRun(); !got.Equal(tt.wantLastRun) {
+				t.Errorf("LastRun() = %v, want %v", got, tt.wantLastRun)
+			}
+
+			if got := s.NextRun(); !got.IsZero() {
+				t.Errorf("NextRun() after Stop() = %v, want the zero time", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"slices"
+	"sync"
+)
+
+// SchedulerLimits Capacity of a scheduler; a zero value means there is no limit.
+type SchedulerLimits struct {
+	// MaxRunning Maximum number of jobs that run at the same time.
+	MaxRunning int
+	// MaxCPUPercentage Maximum sum of the CPU percentages of the jobs that run at the same time.
+	MaxCPUPercentage int64
+	// MaxMemoryBytes Maximum sum of the memory limits of the jobs that run at the same time.
+	MaxMemoryBytes uint64
+}
+
+// Validate Ensure the limits are valid.
+func (l SchedulerLimits) Validate() error {
+	if l.MaxRunning < 0 {
+		return fmt.Errorf("max running jobs cannot be negative: %d", l.MaxRunning)
+	}
+
+	if l.MaxCPUPercentage < 0 {
+		return fmt.Errorf("max CPU percentage cannot be negative: %d", l.MaxCPUPercentage)
+	}
+
+	return nil
+}
+
+// Scheduler Starts jobs while there is capacity for them, holding the rest in a queue and starting them by priority as
+// running jobs end; queued jobs with the same priority start in the order they were submitted.
+type Scheduler struct {
+	mu         sync.Mutex
+	limits     SchedulerLimits
+	queue      []*Job
+	running    map[*Job]struct{}
+	cpuUsed    int64
+	memoryUsed uint64
+}
+
+// NewScheduler Create a scheduler with the given capacity.
+func NewScheduler(limits SchedulerLimits) *Scheduler {
+	return &Scheduler{
+		limits:  limits,
+		running: make(map[*Job]struct{}),
+	}
+}
+
+// Admit Ensure a job with the resource limits could ever run, i.e. that its resources fit within the scheduler's total
+// budget. A job without a limit on a resource that has a budget is rejected since its usage couldn't be accounted for.
+func (s *Scheduler) Admit(resources cgroups.Resources) error {
+	if s.limits.MaxCPUPercentage > 0 && resources.CPUPercentage <= 0 {
+		return fmt.Errorf("a CPU limit is required by the scheduler's budget of %d", s.limits.MaxCPUPercentage)
+	}
+
+	if s.limits.MaxMemoryBytes > 0 && resources.MemoryBytes == 0 {
+		return fmt.Errorf("a memory limit is required by the scheduler's budget of %d bytes", s.limits.MaxMemoryBytes)
+	}
+
+	if s.limits.MaxCPUPercentage > 0 && int64(resources.CPUPercentage) > s.limits.MaxCPUPercentage {
+		return fmt.Errorf(
+			"job CPU percentage %d exceeds the scheduler's budget of %d", resources.CPUPercentage,
+			s.limits.MaxCPUPercentage,
+		)
+	}
+
+	if s.limits.MaxMemoryBytes > 0 && resources.MemoryBytes > s.limits.MaxMemoryBytes {
+		return fmt.Errorf(
+			"job memory limit of %d bytes exceeds the scheduler's budget of %d bytes", resources.MemoryBytes,
+			s.limits.MaxMemoryBytes,
+		)
+	}
+
+	return nil
+}
+
+// Submit Start the job if there is capacity for it and no job is queued ahead of it, otherwise queue it with the
+// queued status behind every queued job with the same or a higher priority. A job that allows preemption and would be
+// first in the queue stops running jobs with a lower priority if that makes room for it; it is started once they end.
+// Returns an error if the job isn't ready, can never run or could not be started.
+func (s *Scheduler) Submit(job *Job) error {
+	if status := job.Status(); status != ReadyStatus {
+		return &StatusError{ID: job.ID(), Op: "submit", Status: status, Err: ErrInvalidTransition}
+	}
+
+	if err := s.Admit(job.Limits()); err != nil {
+		return err
+	}
+
+	job.mu.Lock()
+	job.scheduler = s
+	job.mu.Unlock()
+
+	s.mu.Lock()
+
+	position := s.queuePosition(job)
+
+	if position > 0 || !s.fits(job) {
+		s.queue = slices.Insert(s.queue, position, job)
+
+		var victims []*Job
+
+		if position == 0 && job.preempt {
+			victims = s.victims(job)
+		}
+
+		// The status is changed before the queue is unlocked so that a job started right away is never marked queued
+		logger.Info("Queued job", "id", job.ID(), "position", position+1, "priority", job.priority)
+		job.updateStatus(QueuedStatus)
+		s.mu.Unlock()
+
+		// Each victim releases its capacity as it ends, which starts the job once there is room for it
+		for _, victim := range victims {
+			logger.Info("Preempting job", "id", victim.ID(), "priority", victim.priority, "by", job.ID())
+
+			if err := victim.stop(fmt.Sprintf("%s %s", PreemptedInfo, job.ID())); err != nil {
+				logger.Warn("Failed to preempt job", "id", victim.ID(), "err", err)
+			}
+		}
+
+		return nil
+	}
+
+	s.reserve(job)
+	s.mu.Unlock()
+
+	return job.Start()
+}
+
+// queuePosition Returns the index in the queue that the job would be inserted at, i.e. behind every queued job with the
+// same or a higher priority.
+func (s *Scheduler) queuePosition(job *Job) int {
+	for i, queued := range s.queue {
+		if queued.priority < job.priority {
+			return i
+		}
+	}
+
+	return len(s.queue)
+}
+
+// victims Returns the running jobs with a lower priority than the job that need to be stopped to make room for it,
+// choosing the lowest priority and most recently created jobs first; nil if stopping every such job wouldn't make room.
+func (s *Scheduler) victims(job *Job) []*Job {
+	var candidates []*Job
+
+	for running := range s.running {
+		if running.priority < job.priority {
+			candidates = append(candidates, running)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b *Job) int {
+		if a.priority != b.priority {
+			return a.priority - b.priority
+		}
+
+		return b.created.Compare(a.created)
+	})
+
+	limits := job.Limits()
+	count, cpu, memory := len(s.running), s.cpuUsed, s.memoryUsed
+
+	for i, victim := range candidates {
+		count--
+		cpu -= int64(victim.Limits().CPUPercentage)
+		memory -= victim.Limits().MemoryBytes
+
+		if s.fitsUsage(limits, count, cpu, memory) {
+			return candidates[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// Position Returns the job's position in the queue starting at one; zero if the job isn't queued.
+func (s *Scheduler) Position(job *Job) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Index(s.queue, job) + 1
+}
+
+// release Free the capacity used by a job that ended or remove it from the queue, then start queued jobs that now fit.
+func (s *Scheduler) release(job *Job) {
+	s.mu.Lock()
+
+	if _, ok := s.running[job]; ok {
+		delete(s.running, job)
+
+		s.cpuUsed -= int64(job.Limits().CPUPercentage)
+		s.memoryUsed -= job.Limits().MemoryBytes
+	}
+
+	s.queue = slices.DeleteFunc(s.queue, func(queued *Job) bool { return queued == job })
+
+	// Jobs are started in order of priority, so a job that doesn't fit holds back every job queued behind it
+	var ready []*Job
+
+	for len(s.queue) > 0 && s.fits(s.queue[0]) {
+		next := s.queue[0]
+		s.queue = s.queue[1:]
+		s.reserve(next)
+
+		ready = append(ready, next)
+	}
+
+	s.mu.Unlock()
+
+	for _, next := range ready {
+		logger.Info("Starting queued job", "id", next.ID())
+
+		if err := next.Start(); err != nil {
+			logger.Error("Failed to start queued job", "id", next.ID(), "err", err)
+		}
+	}
+}
+
+// fits Returns true if there is capacity to run the job along with the jobs that are already running.
+func (s *Scheduler) fits(job *Job) bool {
+	return s.fitsUsage(job.Limits(), len(s.running), s.cpuUsed, s.memoryUsed)
+}
+
+// fitsUsage Returns true if there is capacity to run a job with the resource limits along with jobs using the given
+// number of jobs, CPU percentage and memory.
+func (s *Scheduler) fitsUsage(limits cgroups.Resources, running int, cpu int64, memory uint64) bool {
+	if s.limits.MaxRunning > 0 && running >= s.limits.MaxRunning {
+		return false
+	}
+
+	if s.limits.MaxCPUPercentage > 0 && cpu+int64(limits.CPUPercentage) > s.limits.MaxCPUPercentage {
+		return false
+	}
+
+	if s.limits.MaxMemoryBytes > 0 && memory+limits.MemoryBytes > s.limits.MaxMemoryBytes {
+		return false
+	}
+
+	return true
+}
+
+// adopt Use capacity for a job that is already running, e.g. one reattached after a restart, even if the scheduler
+// doesn't have room for it; the capacity is released once the job ends.
+func (s *Scheduler) adopt(job *Job) {
+	job.mu.Lock()
+	job.scheduler = s
+	job.mu.Unlock()
+
+	s.mu.Lock()
+	s.reserve(job)
+	s.mu.Unlock()
+}
+
+// reserve Use capacity for the job so that it can run.
+func (s *Scheduler) reserve(job *Job) {
+	s.running[job] = struct{}{}
+	s.cpuUsed += int64(job.Limits().CPUPercentage)
+	s.memoryUsed += job.Limits().MemoryBytes
+}
+
+// QueuePosition Returns the job's position in the queue of its scheduler starting at one; zero if the job isn't queued.
+func (j *Job) QueuePosition() int {
+	j.mu.Lock()
+	scheduler := j.scheduler
+	j.mu.Unlock()
+
+	if scheduler == nil {
+		return 0
+	}
+
+	return scheduler.Position(j)
+}
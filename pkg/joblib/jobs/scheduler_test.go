@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Admit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    SchedulerLimits
+		resources cgroups.Resources
+		wantErr   bool
+	}{
+		{
+			name:      "Should admit job within budget",
+			limits:    SchedulerLimits{MaxCPUPercentage: 200, MaxMemoryBytes: 1024},
+			resources: cgroups.Resources{CPUPercentage: 200, MemoryBytes: 1024},
+		},
+		{
+			name:      "Should admit any job without budget",
+			limits:    SchedulerLimits{MaxRunning: 1},
+			resources: cgroups.Resources{CPUPercentage: 800, MemoryBytes: 1 << 40},
+		},
+		{
+			name:      "Should reject job exceeding CPU budget",
+			limits:    SchedulerLimits{MaxCPUPercentage: 100},
+			resources: cgroups.Resources{CPUPercentage: 150},
+			wantErr:   true,
+		},
+		{
+			name:      "Should reject job without a CPU limit against a CPU budget",
+			limits:    SchedulerLimits{MaxCPUPercentage: 100},
+			resources: cgroups.Resources{MemoryBytes: 1024},
+			wantErr:   true,
+		},
+		{
+			name:      "Should reject job without a memory limit against a memory budget",
+			limits:    SchedulerLimits{MaxMemoryBytes: 1024},
+			resources: cgroups.Resources{CPUPercentage: 50},
+			wantErr:   true,
+		},
+		{
+			name:      "Should reject job exceeding memory budget",
+			limits:    SchedulerLimits{MaxMemoryBytes: 1024},
+			resources: cgroups.Resources{MemoryBytes: 2048},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewScheduler(tt.limits).Admit(tt.resources); (err != nil) != tt.wantErr {
+				t.Errorf("Admit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduler_SubmitUnlimited(t *testing.T) {
+	scheduler := NewScheduler(SchedulerLimits{MaxCPUPercentage: 100, MaxMemoryBytes: 1024})
+	job := &Job{id: "unlimited", clock: &testClock{time: UnixEpoch()}, status: ReadyStatus}
+
+	if err := scheduler.Submit(job); err == nil {
+		t.Fatal("Submit() of a job without limits succeeded, want an error")
+	}
+
+	if job.Status() != ReadyStatus {
+		t.Errorf("Submit() status = %s, want %s", job.Status(), ReadyStatus)
+	}
+
+	if len(scheduler.running) != 0 || len(scheduler.queue) != 0 {
+		t.Errorf("Submit() reserved capacity for or queued a job without limits")
+	}
+}
+
+func TestScheduler_Queue(t *testing.T) {
+	clock := &testClock{time: UnixEpoch()}
+	scheduler := NewScheduler(SchedulerLimits{MaxRunning: 1})
+
+	newJob := func(id string) *Job {
+		return &Job{id: id, clock: clock, status: ReadyStatus, scheduler: scheduler}
+	}
+
+	// The running job is reserved directly since starting it requires a cgroup
+	running := newJob("running")
+	scheduler.reserve(running)
+
+	first, second := newJob("first"), newJob("second")
+
+	for _, job := range []*Job{first, second} {
+		if err := scheduler.Submit(job); err != nil {
+			t.Fatal(err)
+		}
+
+		if job.Status() != QueuedStatus {
+			t.Errorf("Submit() status of %s = %s, want %s", job.id, job.Status(), QueuedStatus)
+		}
+	}
+
+	if got := first.QueuePosition(); got != 1 {
+		t.Errorf("QueuePosition() of first = %d, want 1", got)
+	}
+
+	if got := second.QueuePosition(); got != 2 {
+		t.Errorf("QueuePosition() of second = %d, want 2", got)
+	}
+
+	if got := running.QueuePosition(); got != 0 {
+		t.Errorf("QueuePosition() of running = %d, want 0", got)
+	}
+
+	// A queued job that ends leaves the queue without starting any job since the running job still uses the capacity
+	first.updateStatus(StoppedStatus)
+
+	if got := first.QueuePosition(); got != 0 {
+		t.Errorf("QueuePosition() of stopped = %d, want 0", got)
+	}
+
+	if got := second.QueuePosition(); got != 1 {
+		t.Errorf("QueuePosition() of second = %d, want 1", got)
+	}
+
+	if second.Status() != QueuedStatus {
+		t.Errorf("status of second = %s, want %s", second.Status(), QueuedStatus)
+	}
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	clock := &testClock{time: UnixEpoch()}
+	scheduler := NewScheduler(SchedulerLimits{MaxRunning: 1})
+	scheduler.reserve(&Job{id: "running", clock: clock})
+
+	submitted := []*Job{
+		{id: "low", priority: -1},
+		{id: "normal"},
+		{id: "high", priority: 10},
+		{id: "normal-later"},
+	}
+
+	for _, job := range submitted {
+		job.clock = clock
+		job.status = ReadyStatus
+
+		if err := scheduler.Submit(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+
+	for _, job := range scheduler.queue {
+		got = append(got, job.id)
+	}
+
+	if want := []string{"high", "normal", "normal-later", "low"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queue = %v, want %v", got, want)
+	}
+}
+
+func TestScheduler_victims(t *testing.T) {
+	created := UnixEpoch()
+
+	tests := []struct {
+		name    string
+		limits  SchedulerLimits
+		running []*Job
+		job     *Job
+		want    []string
+	}{
+		{
+			name:   "Should choose lowest priority and newest job first",
+			limits: SchedulerLimits{MaxRunning: 2},
+			running: []*Job{
+				{id: "old", priority: 1, created: created},
+				{id: "new", priority: 1, created: created.Add(time.Minute)},
+			},
+			job:  &Job{id: "urgent", priority: 5},
+			want: []string{"new"},
+		},
+		{
+			name:   "Should choose as many jobs as needed to make room",
+			limits: SchedulerLimits{MaxMemoryBytes: 100},
+			running: []*Job{
+				{id: "small", resourceLimits: cgroups.Resources{MemoryBytes: 30}},
+				{id: "medium", priority: 1, resourceLimits: cgroups.Resources{MemoryBytes: 40}},
+				{id: "other", priority: 2, resourceLimits: cgroups.Resources{MemoryBytes: 30}},
+			},
+			job:  &Job{id: "urgent", priority: 5, resourceLimits: cgroups.Resources{MemoryBytes: 70}},
+			want: []string{"small", "medium"},
+		},
+		{
+			name:   "Should not preempt jobs with the same or a higher priority",
+			limits: SchedulerLimits{MaxRunning: 2},
+			running: []*Job{
+				{id: "same", priority: 5},
+				{id: "higher", priority: 6},
+			},
+			job: &Job{id: "urgent", priority: 5},
+		},
+		{
+			name:   "Should not preempt when stopping every lower priority job wouldn't make room",
+			limits: SchedulerLimits{MaxCPUPercentage: 100},
+			running: []*Job{
+				{id: "low", resourceLimits: cgroups.Resources{CPUPercentage: 20}},
+				{id: "higher", priority: 9, resourceLimits: cgroups.Resources{CPUPercentage: 60}},
+			},
+			job: &Job{id: "urgent", priority: 5, resourceLimits: cgroups.Resources{CPUPercentage: 50}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := NewScheduler(tt.limits)
+
+			for _, job := range tt.running {
+				scheduler.reserve(job)
+			}
+
+			var got []string
+
+			for _, victim := range scheduler.victims(tt.job) {
+				got = append(got, victim.id)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("victims() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
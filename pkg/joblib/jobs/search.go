@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+)
+
+const (
+	// StdoutStream Output the job's command wrote to stdout.
+	StdoutStream = Stream(stdoutName)
+	// StderrStream Output the job's command wrote to stderr.
+	StderrStream = Stream(stderrName)
+
+	// maxSearchLineBytes Longest part of a line that is matched and returned by a search; the rest of a longer line is
+	// skipped.
+	maxSearchLineBytes = 64 * 1024
+)
+
+// Stream Stream of a job's output.
+type Stream string
+
+// SearchOptions Optional settings for searching a job's output.
+type SearchOptions struct {
+	// ContextLines Number of lines before and after each matching line that are also returned.
+	ContextLines int
+	// Streams Streams of output that are searched; both stdout and stderr are searched if empty.
+	Streams []Stream
+	// Filters Only lines that match every filter can match the search; requires the job's output to be in JSONFormat.
+	Filters []FieldFilter
+}
+
+// Match Line of a job's output that matched a search or is context around such a line.
+type Match struct {
+	Stream Stream
+	// Offset Byte offset of the start of the line in its stream.
+	Offset int64
+	// LineNumber Number of the line in its stream, starting at one.
+	LineNumber int64
+	// Time When the line was written, to the resolution of the output's index.
+	Time time.Time
+	// Line Content of the line without its newline.
+	Line []byte
+	// Context True if the line didn't match but is context around a line that did.
+	Context bool
+}
+
+// searchLine Line of output read while searching.
+type searchLine struct {
+	offset int64
+	number int64
+	data   []byte
+}
+
+// Search Run a regular expression over each line of the job's output, calling send with every matching line along with
+// its context. Searching stops at the first error returned by send or when the context is done.
+func (j *Job) Search(ctx context.Context, pattern *regexp.Regexp, options SearchOptions, send func(Match) error) error {
+	if options.ContextLines < 0 {
+		return fmt.Errorf("context lines cannot be negative: %d", options.ContextLines)
+	}
+
+	streams := options.Streams
+
+	if len(streams) == 0 {
+		streams = []Stream{StdoutStream, StderrStream}
+	}
+
+	for _, stream := range streams {
+		if stream != StdoutStream && stream != StderrStream {
+			return fmt.Errorf("unknown output stream: %s", stream)
+		}
+	}
+
+	if len(options.Filters) > 0 && j.OutputFormat() != JSONFormat {
+		return ErrNotStructured
+	}
+
+	matches := func(data []byte) bool {
+		return pattern.Match(data) && matchesFilters(options.Filters, ParseLine(j.outputFormat, data))
+	}
+
+	stdout, stderr, err := j.Output(OutputRange{})
+
+	if err != nil {
+		return err
+	}
+
+	defer stdout.Close()
+	defer stderr.Close()
+
+	for _, stream := range streams {
+		reader, index := stdout, j.stdoutIndex
+
+		if stream == StderrStream {
+			reader, index = stderr, j.stderrIndex
+		}
+
+		if err := searchStream(ctx, reader, stream, index, matches, options.ContextLines, send); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// searchStream Search a single stream of output.
+func searchStream(ctx context.Context, r io.Reader, stream Stream, index *outputIndex, matches func([]byte) bool, contextLines int, send func(Match) error) error {
+	reader := bufio.NewReader(r)
+
+	// Lines before the current one that can still be sent as context, and how many lines after the last match are
+	// still to be sent as context
+	var before []searchLine
+	var after int
+
+	sendLine := func(line searchLine, isContext bool) error {
+		return send(Match{
+			Stream:     stream,
+			Offset:     line.offset,
+			LineNumber: line.number,
+			Time:       index.timeAt(line.offset),
+			Line:       line.data,
+			Context:    isContext,
+		})
+	}
+
+	var offset int64
+
+	for number := int64(1); ; number++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, size, err := readLine(reader)
+
+		if size == 0 && err == io.EOF {
+			return nil
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+
+		line := searchLine{offset: offset, number: number, data: data}
+		offset += size
+
+		switch {
+		case matches(data):
+			for _, b := range before {
+				if err := sendLine(b, true); err != nil {
+					return err
+				}
+			}
+
+			before = before[:0]
+			after = contextLines
+
+			if err := sendLine(line, false); err != nil {
+				return err
+			}
+		case after > 0:
+			after--
+
+			if err := sendLine(line, true); err != nil {
+				return err
+			}
+		case contextLines > 0:
+			if len(before) == contextLines {
+				before = append(before[:0], before[1:]...)
+			}
+
+			before = append(before, line)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// readLine Read the next line without its newline, keeping at most maxSearchLineBytes of it. Returns the line along with
+// the number of bytes read, including the newline and anything that wasn't kept.
+func readLine(reader *bufio.Reader) ([]byte, int64, error) {
+	var line []byte
+	var size int64
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		size += int64(len(chunk))
+
+		if room := maxSearchLineBytes - len(line); room > 0 {
+			line = append(line, chunk[:min(room, len(chunk))]...)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		return bytes.TrimSuffix(line, []byte{'\n'}), size, err
+	}
+}
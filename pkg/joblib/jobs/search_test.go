@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestJob_Search(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		options SearchOptions
+		want    []Match
+		wantErr bool
+	}{
+		{
+			name:    "Should find matching lines in every stream",
+			pattern: "error",
+			options: SearchOptions{},
+			want: []Match{
+				{Stream: StdoutStream, Offset: 8, LineNumber: 3, Line: []byte("error: disk full")},
+				{Stream: StderrStream, Offset: 0, LineNumber: 1, Line: []byte("error: retrying")},
+			},
+		},
+		{
+			name:    "Should include context lines around matches",
+			pattern: "disk",
+			options: SearchOptions{ContextLines: 1, Streams: []Stream{StdoutStream}},
+			want: []Match{
+				{Stream: StdoutStream, Offset: 4, LineNumber: 2, Line: []byte("two"), Context: true},
+				{Stream: StdoutStream, Offset: 8, LineNumber: 3, Line: []byte("error: disk full")},
+				{Stream: StdoutStream, Offset: 25, LineNumber: 4, Line: []byte("four"), Context: true},
+			},
+		},
+		{
+			name:    "Should reject unknown stream",
+			pattern: "error",
+			options: SearchOptions{Streams: []Stream{"stdin"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{time: UnixEpoch()}
+
+			j := &Job{
+				clock:       clock,
+				outputDir:   t.TempDir(),
+				stdoutIndex: newOutputIndex(),
+				stderrIndex: newOutputIndex(),
+			}
+
+			writeOutput(
+				t, j, RotationPolicy{MaxSegmentBytes: 8}, "one\ntwo\nerror: disk full\nfour\nfive\n", "error: retrying\n",
+			)
+
+			var got []Match
+
+			err := j.Search(context.Background(), regexp.MustCompile(tt.pattern), tt.options, func(m Match) error {
+				got = append(got, m)
+
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search() got %d matches, want %d: %v", len(got), len(tt.want), got)
+			}
+
+			for i := range got {
+				want := tt.want[i]
+				want.Time = UnixEpoch()
+
+				if got[i].Stream != want.Stream || got[i].Offset != want.Offset || got[i].LineNumber != want.LineNumber ||
+					string(got[i].Line) != string(want.Line) || got[i].Context != want.Context || !got[i].Time.Equal(want.Time) {
+					t.Errorf("Search() match %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// LeaderTarget Deliver the signal only to the job's command, i.e. the process that was forked.
+	LeaderTarget = SignalTarget("leader")
+	// GroupTarget Deliver the signal to the job's process group.
+	GroupTarget = SignalTarget("group")
+	// CgroupTarget Deliver the signal to every process in the job's cgroup.
+	CgroupTarget = SignalTarget("cgroup")
+)
+
+// SignalTarget Processes of a job that a signal is delivered to.
+type SignalTarget string
+
+// SignalEvent When a signal was sent to the job and which processes it was sent to.
+type SignalEvent struct {
+	Signal syscall.Signal
+	Target SignalTarget
+	SentAt time.Time
+}
+
+// ParseSignal Parse a signal from its name (e.g. "HUP" or "SIGHUP") or its number.
+func ParseSignal(name string) (syscall.Signal, error) {
+	if num, err := strconv.Atoi(name); err == nil {
+		if unix.SignalName(syscall.Signal(num)) == "" {
+			return 0, fmt.Errorf("unknown signal number: %d", num)
+		}
+
+		return syscall.Signal(num), nil
+	}
+
+	name = strings.ToUpper(name)
+
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	if sig := unix.SignalNum(name); sig != 0 {
+		return sig, nil
+	}
+
+	return 0, fmt.Errorf("unknown signal: %s", name)
+}
+
+// Signal Send a signal to the processes of the job described by target.
+func (j *Job) Signal(sig syscall.Signal, target SignalTarget) error {
+	logger.Info("Signaling job", "id", j.id, "signal", unix.SignalName(sig), "target", target)
+
+	if status := j.Status(); status != RunningStatus {
+		return &StatusError{ID: j.id, Op: "signal", Status: status, Err: ErrJobNotRunning}
+	}
+
+	process := j.process()
+
+	// A reattached job's command may have exited while other processes in its cgroup are still running
+	if process == nil && target != CgroupTarget {
+		return fmt.Errorf("%w: job command has exited; only the %s target can be signaled", ErrJobNotRunning, CgroupTarget)
+	}
+
+	var pid int
+
+	if process != nil {
+		pid = process.Pid
+	}
+
+	switch target {
+	case LeaderTarget:
+		if err := process.Signal(sig); err != nil {
+			return err
+		}
+	case GroupTarget:
+		// The job's command is started with Setpgid so its PGID is the same as its PID
+		if err := syscall.Kill(-pid, sig); err != nil {
+			return err
+		}
+	case CgroupTarget:
+		pids, err := j.cgroup.Procs()
+
+		if err != nil {
+			return err
+		}
+
+		for _, p := range pids {
+			if err := syscall.Kill(p, sig); err != nil && err != syscall.ESRCH {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown signal target: %s", target)
+	}
+
+	j.mu.Lock()
+	j.signalEvents = append(j.signalEvents, SignalEvent{Signal: sig, Target: target, SentAt: j.clock.Now()})
+	j.mu.Unlock()
+
+	j.save()
+
+	return nil
+}
+
+// SignalEvents Returns the signals that have been sent to the job.
+func (j *Job) SignalEvents() []SignalEvent {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]SignalEvent(nil), j.signalEvents...)
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		signal  string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{
+			name:   "Should parse signal name without prefix",
+			signal: "HUP",
+			want:   syscall.SIGHUP,
+		},
+		{
+			name:   "Should parse signal name with prefix",
+			signal: "SIGUSR1",
+			want:   syscall.SIGUSR1,
+		},
+		{
+			name:   "Should parse lowercase signal name",
+			signal: "int",
+			want:   syscall.SIGINT,
+		},
+		{
+			name:   "Should parse signal number",
+			signal: "15",
+			want:   syscall.SIGTERM,
+		},
+		{
+			name:    "Should fail to parse unknown signal name",
+			signal:  "NOPE",
+			wantErr: true,
+		},
+		{
+			name:    "Should fail to parse unknown signal number",
+			signal:  "1000",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSignal(tt.signal)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSignal() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseSignal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
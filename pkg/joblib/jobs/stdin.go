@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// openStdin Create the pipe used to stream data to the stdin of the job's command, if the job accepts streamed stdin.
+func (j *Job) openStdin() error {
+	if !j.stdinStream {
+		return nil
+	}
+
+	reader, writer, err := os.Pipe()
+
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stdinReader = reader
+	j.stdinWriter = writer
+
+	return nil
+}
+
+// setStdin Set the stdin of the command; every attempt of a job started with stdin data receives all the data.
+func (j *Job) setStdin(cmd *exec.Cmd) {
+	if j.stdinStream {
+		// Pass the pipe directly, otherwise exec would wait on a goroutine copying from it before Wait returns
+		cmd.Stdin = j.stdinReader
+	} else if j.stdin != nil {
+		cmd.Stdin = bytes.NewReader(j.stdin)
+	}
+}
+
+// closeStdinPipe Close both ends of the stdin pipe once the job's command won't be executed again.
+func (j *Job) closeStdinPipe() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stdinReader != nil {
+		j.stdinReader.Close()
+	}
+
+	if j.stdinWriter != nil {
+		j.stdinWriter.Close()
+		j.stdinWriter = nil
+	}
+}
+
+// WriteStdin Write data to the stdin of the job's command; the job must have been started with streamed stdin.
+func (j *Job) WriteStdin(data []byte) error {
+	writer, err := j.stdinPipe()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+
+	return err
+}
+
+// CloseStdin Close the stdin of the job's command so that it reads EOF; further writes will fail.
+func (j *Job) CloseStdin() error {
+	if _, err := j.stdinPipe(); err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stdinWriter == nil {
+		return nil
+	}
+
+	err := j.stdinWriter.Close()
+	j.stdinWriter = nil
+
+	return err
+}
+
+// stdinPipe Returns the writer used to stream data to the stdin of the job's command.
+func (j *Job) stdinPipe() (io.Writer, error) {
+	if !j.stdinStream {
+		return nil, ErrNoStdinStream
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stdinWriter == nil {
+		return nil, fmt.Errorf("%w: job stdin is closed", ErrNoStdinStream)
+	}
+
+	return j.stdinWriter, nil
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestJob_WriteStdin(t *testing.T) {
+	j := &Job{stdinStream: true}
+
+	if err := j.openStdin(); err != nil {
+		t.Fatalf("openStdin() error = %v", err)
+	}
+
+	defer j.closeStdinPipe()
+
+	var stdout bytes.Buffer
+
+	cmd := exec.Command("cat")
+	cmd.Stdout = &stdout
+	j.setStdin(cmd)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	if err := j.WriteStdin([]byte("some input")); err != nil {
+		t.Errorf("WriteStdin() error = %v", err)
+	}
+
+	if err := j.CloseStdin(); err != nil {
+		t.Errorf("CloseStdin() error = %v", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed waiting for command: %v", err)
+	}
+
+	if got := stdout.String(); got != "some input" {
+		t.Errorf("stdout = %q, want %q", got, "some input")
+	}
+
+	if err := j.WriteStdin([]byte("more input")); err == nil {
+		t.Errorf("WriteStdin() after CloseStdin() error = nil, want error")
+	}
+}
+
+func TestJob_WriteStdinWithoutStream(t *testing.T) {
+	j := &Job{stdin: []byte("some input")}
+
+	if err := j.WriteStdin([]byte("more input")); err == nil {
+		t.Errorf("WriteStdin() error = nil, want error")
+	}
+}
+
+func TestJob_WriteStdinQueued(t *testing.T) {
+	j := &Job{status: QueuedStatus, clock: &testClock{time: UnixEpoch()}, stdinStream: true}
+
+	if err := j.openStdin(); err != nil {
+		t.Fatalf("openStdin() error = %v", err)
+	}
+
+	if err := j.WriteStdin([]byte("some input")); err != nil {
+		t.Errorf("WriteStdin() error = %v", err)
+	}
+
+	if err := j.updateStatus(StoppedStatus); err != nil {
+		t.Fatalf("updateStatus() error = %v", err)
+	}
+
+	if err := j.WriteStdin([]byte("more input")); err == nil {
+		t.Errorf("WriteStdin() after the job ended error = nil, want error")
+	}
+}
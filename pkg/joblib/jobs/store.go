@@ -0,0 +1,436 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStorePath File that the metadata of jobs is stored in when no other file is given.
+	DefaultStorePath = "/var/lib/job-worker/jobs.log"
+
+	// RestartedInfo Status info of a job that was running when the process managing it exited.
+	RestartedInfo = "server restarted while the job was running"
+
+	saveOp   = "save"
+	deleteOp = "delete"
+)
+
+// Store Persists the metadata of jobs so that they can be restored after the process managing them restarts.
+type Store interface {
+	// Save Persist the record, replacing any earlier record of the same job.
+	Save(record Record) error
+	// Delete Remove the record of the job with the ID.
+	Delete(id string) error
+	// Load Returns the records of every job that has been saved and not deleted.
+	Load() ([]Record, error)
+}
+
+// Record Metadata of a job along with the location of its output; everything needed to query a job and read its
+// output after a restart. Env is kept so that commands executed in reattached jobs get the job's environment; since it
+// may contain secrets the store is only readable by the server's user.
+type Record struct {
+	ID             string            `json:"id"`
+	Created        time.Time         `json:"created"`
+	Status         Status            `json:"status"`
+	StatusInfo     string            `json:"statusInfo"`
+	StatusChanges  []StatusChange    `json:"statusChanges"`
+	Path           string            `json:"path"`
+	Args           []string          `json:"args"`
+	ResourceLimits cgroups.Resources `json:"resourceLimits"`
+	SignalEvents   []SignalEvent     `json:"signalEvents"`
+	Timeout        time.Duration     `json:"timeout"`
+	Deadline       time.Time         `json:"deadline"`
+	RestartPolicy  RestartPolicy     `json:"restartPolicy"`
+	Runs           []Run             `json:"runs"`
+	Env            []string          `json:"env"`
+	Dir            string            `json:"dir"`
+	Umask          *int              `json:"umask"`
+	TTY            bool              `json:"tty"`
+	OutputDir      string            `json:"outputDir"`
+	OutputFormat   OutputFormat      `json:"outputFormat"`
+	Stdout         IndexSnapshot     `json:"stdout"`
+	Stderr         IndexSnapshot     `json:"stderr"`
+	Ended          time.Time         `json:"ended"`
+	LastAccessed   time.Time         `json:"lastAccessed"`
+	OutputDeleted  bool              `json:"outputDeleted"`
+	Priority       int               `json:"priority"`
+	Owner          string            `json:"owner"`
+	Labels         map[string]string `json:"labels"`
+	Isolation      Isolation         `json:"isolation"`
+}
+
+// IndexSnapshot Copy of the index of a stream of output, locating its segments along with the lines and times within
+// it.
+type IndexSnapshot struct {
+	SegmentStarts []int64      `json:"segmentStarts"`
+	Entries       []IndexEntry `json:"entries"`
+	Size          int64        `json:"size"`
+	Lines         int64        `json:"lines"`
+	LastByte      byte         `json:"lastByte"`
+}
+
+// IndexEntry Position in a stream of output along with the number of newlines before it and when it was written.
+type IndexEntry struct {
+	Offset int64     `json:"offset"`
+	Lines  int64     `json:"lines"`
+	Time   time.Time `json:"time"`
+}
+
+// snapshot Copy the index so that it can be persisted.
+func (idx *outputIndex) snapshot() IndexSnapshot {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	entries := make([]IndexEntry, 0, len(idx.entries))
+
+	for _, e := range idx.entries {
+		entries = append(entries, IndexEntry{Offset: e.offset, Lines: e.lines, Time: e.time})
+	}
+
+	return IndexSnapshot{
+		SegmentStarts: append([]int64(nil), idx.segmentStarts...),
+		Entries:       entries,
+		Size:          idx.size,
+		Lines:         idx.lines,
+		LastByte:      idx.lastByte,
+	}
+}
+
+// restoreIndex Create an index from a persisted copy.
+func restoreIndex(s IndexSnapshot) *outputIndex {
+	idx := &outputIndex{
+		segmentStarts: append([]int64(nil), s.SegmentStarts...),
+		size:          s.Size,
+		lines:         s.Lines,
+		lastByte:      s.LastByte,
+	}
+
+	for _, e := range s.Entries {
+		idx.entries = append(idx.entries, indexEntry{offset: e.Offset, lines: e.Lines, time: e.Time})
+	}
+
+	return idx
+}
+
+// Record Returns the metadata of the job so that it can be persisted.
+func (j *Job) Record() Record {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return Record{
+		ID:             j.id,
+		Created:        j.created,
+		Status:         j.status,
+		StatusInfo:     j.statusInfo,
+		StatusChanges:  append([]StatusChange(nil), j.statusChanges...),
+		Path:           j.path,
+		Args:           append([]string{j.name}, j.args...),
+		ResourceLimits: j.resourceLimits,
+		SignalEvents:   append([]SignalEvent(nil), j.signalEvents...),
+		Timeout:        j.timeout,
+		Deadline:       j.deadline,
+		RestartPolicy:  j.restartPolicy,
+		Runs:           append([]Run(nil), j.runs...),
+		Env:            append([]string(nil), j.env...),
+		Dir:            j.dir,
+		Umask:          j.umask,
+		TTY:            j.tty,
+		OutputDir:      j.outputDir,
+		OutputFormat:   j.outputFormat,
+		Stdout:         j.stdoutIndex.snapshot(),
+		Stderr:         j.stderrIndex.snapshot(),
+		Ended:          j.ended,
+		LastAccessed:   j.lastAccessed,
+		OutputDeleted:  j.outputDeleted,
+		Priority:       j.priority,
+		Owner:          j.owner,
+		Labels:         maps.Clone(j.labels),
+		Isolation:      j.isolation,
+	}
+}
+
+// restoreJob Recreate a job from its persisted metadata without changing its status.
+func restoreJob(record Record, clock clock.Clock, store Store) *Job {
+	job := &Job{
+		id:             record.ID,
+		created:        record.Created,
+		status:         record.Status,
+		statusInfo:     record.StatusInfo,
+		statusChanges:  append(make([]StatusChange, 0), record.StatusChanges...),
+		command:        &exec.Cmd{Path: record.Path, Args: record.Args},
+		path:           record.Path,
+		resourceLimits: record.ResourceLimits,
+		clock:          clock,
+		outputDir:      record.OutputDir,
+		signalEvents:   append(make([]SignalEvent, 0), record.SignalEvents...),
+		timeout:        record.Timeout,
+		deadline:       record.Deadline,
+		halt:           make(chan struct{}),
+		restartPolicy:  record.RestartPolicy,
+		runs:           append(make([]Run, 0), record.Runs...),
+		env:            record.Env,
+		dir:            record.Dir,
+		umask:          record.Umask,
+		tty:            record.TTY,
+		outputFormat:   record.OutputFormat,
+		stdoutIndex:    restoreIndex(record.Stdout),
+		stderrIndex:    restoreIndex(record.Stderr),
+		ended:          record.Ended,
+		lastAccessed:   record.LastAccessed,
+		outputDeleted:  record.OutputDeleted,
+		store:          store,
+		priority:       record.Priority,
+		owner:          record.Owner,
+		labels:         record.Labels,
+		isolation:      record.Isolation,
+	}
+
+	if len(record.Args) > 0 {
+		job.name = record.Args[0]
+		job.args = record.Args[1:]
+	}
+
+	return job
+}
+
+// save Persist the job's metadata if it has a store, logging any failure. The record is taken and saved while no other
+// record of the job is, otherwise an older record could be saved after a newer one and be restored instead of it.
+func (j *Job) save() {
+	if j.store == nil {
+		return
+	}
+
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	if err := j.store.Save(j.Record()); err != nil {
+		logger.Error("Failed to save job", "id", j.id, "err", err)
+	}
+}
+
+// storeEntry Entry of the log written by a FileStore.
+type storeEntry struct {
+	Op     string  `json:"op"`
+	ID     string  `json:"id"`
+	Record *Record `json:"record,omitempty"`
+}
+
+// FileStore Store that appends every change to a log file, one JSON entry per line. The log is compacted when the store
+// is opened so that it only contains the latest record of each job.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	records map[string]Record
+}
+
+// NewFileStore Open the store kept in the file at the path, creating the file if it doesn't exist. The file can only be
+// accessed by the current user since records include the command lines of jobs.
+func NewFileStore(path string) (*FileStore, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("store path must be an absolute path: %s", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{
+		path:    path,
+		records: make(map[string]Record),
+	}
+
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.file = file
+
+	return s, nil
+}
+
+// replay Read every entry of the log, keeping the latest record of each job.
+func (s *FileStore) replay() error {
+	file, err := os.Open(s.path)
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		var entry storeEntry
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// The last entry may have been partially written if the process exited while writing it
+			logger.Warn("Skipping invalid entry in job store", "path", s.path, "line", line, "err", err)
+
+			continue
+		}
+
+		switch entry.Op {
+		case saveOp:
+			if entry.Record != nil {
+				s.records[entry.ID] = *entry.Record
+			}
+		case deleteOp:
+			delete(s.records, entry.ID)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// compact Replace the log with one containing only the current records; the new log is put in place atomically.
+func (s *FileStore) compact() error {
+	tmp := s.path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	// A file left behind by an earlier compaction keeps its mode when it is truncated
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	writer := bufio.NewWriter(file)
+
+	for id, record := range s.records {
+		if err := writeEntry(writer, storeEntry{Op: saveOp, ID: id, Record: &record}); err != nil {
+			file.Close()
+
+			return errors.Join(err, os.Remove(tmp))
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// writeEntry Write an entry to the log as a single line.
+func writeEntry(w io.Writer, entry storeEntry) error {
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+// append Write an entry to the end of the log and flush it to disk.
+func (s *FileStore) append(entry storeEntry) error {
+	if s.file == nil {
+		return errors.New("job store is closed")
+	}
+
+	if err := writeEntry(s.file, entry); err != nil {
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+func (s *FileStore) Save(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(storeEntry{Op: saveOp, ID: record.ID, Record: &record}); err != nil {
+		return err
+	}
+
+	s.records[record.ID] = record
+
+	return nil
+}
+
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(storeEntry{Op: deleteOp, ID: id}); err != nil {
+		return err
+	}
+
+	delete(s.records, id)
+
+	return nil
+}
+
+func (s *FileStore) Load() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]Record, 0, len(s.records))
+
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Close Close the log file; the store cannot be used afterwards.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
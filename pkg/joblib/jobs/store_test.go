@@ -0,0 +1,275 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.log")
+
+	store, err := NewFileStore(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"kept", "updated", "deleted"} {
+		if err := store.Save(Record{ID: id, Status: RunningStatus}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Save(Record{ID: "updated", Status: SucceededStatus}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete("deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	store.Close()
+
+	// A partially written entry is skipped when the store is reopened
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file.WriteString(`{"op":"save","id":"partial","rec`)
+	file.Close()
+
+	// A log left behind by an interrupted compaction doesn't make the store readable by other users
+	if err := os.WriteFile(path+".tmp", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewFileStore(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Close()
+
+	records, err := store.Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Status)
+
+	for _, record := range records {
+		got[record.ID] = record.Status
+	}
+
+	want := map[string]Status{"kept": RunningStatus, "updated": SucceededStatus}
+
+	if len(got) != len(want) {
+		t.Fatalf("Load() = %v, want %v", got, want)
+	}
+
+	for id, status := range want {
+		if got[id] != status {
+			t.Errorf("Load() status of %s = %s, want %s", id, got[id], status)
+		}
+	}
+
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("store file mode = %s, want %s", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestReconciliation_RestoreRecord(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         Status
+		wantStatus     Status
+		wantStatusInfo string
+	}{
+		{
+			name:       "Should restore ended job",
+			status:     SucceededStatus,
+			wantStatus: SucceededStatus,
+		},
+		{
+			name:           "Should mark running job as failed",
+			status:         RunningStatus,
+			wantStatus:     FailedStatus,
+			wantStatusInfo: RestartedInfo,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{time: UnixEpoch()}
+
+			original := &Job{
+				id:          "job",
+				clock:       clock,
+				status:      tt.status,
+				command:     exec.Command("echo", "hello"),
+				path:        "/bin/echo",
+				name:        "echo",
+				args:        []string{"hello"},
+				outputDir:   t.TempDir(),
+				stdoutIndex: newOutputIndex(),
+				stderrIndex: newOutputIndex(),
+			}
+
+			writeOutput(t, original, RotationPolicy{MaxSegmentBytes: 4}, "hello\nworld\n", "")
+
+			store, err := NewFileStore(filepath.Join(t.TempDir(), "jobs.log"))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer store.Close()
+
+			reconciliation := Reconciliation{CgroupRoot: t.TempDir(), WorkerName: "worker", Clock: clock, Store: store}
+			restored, err := reconciliation.Restore([]Record{original.Record()})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			job := restored[0]
+
+			if job.Status() != tt.wantStatus || job.StatusInfo() != tt.wantStatusInfo {
+				t.Errorf("Restore() status = %s (%s), want %s (%s)", job.Status(), job.StatusInfo(), tt.wantStatus, tt.wantStatusInfo)
+			}
+
+			if name, args := job.Command(); name != original.path || len(args) != 2 {
+				t.Errorf("Restore() command = %s %v, want %s %v", name, args, original.path, original.command.Args)
+			}
+
+			stdout, stderr, err := job.Output(OutputRange{TailLines: 1})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer stdout.Close()
+			defer stderr.Close()
+
+			if got, err := io.ReadAll(stdout); err != nil {
+				t.Fatal(err)
+			} else if string(got) != "world\n" {
+				t.Errorf("Output() = %q, want %q", got, "world\n")
+			}
+
+			records, err := store.Load()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Only a job whose status changed while being restored is saved
+			if wantSaved := tt.status != tt.wantStatus; (len(records) == 1) != wantSaved {
+				t.Errorf("Load() = %d records, want saved %v", len(records), wantSaved)
+			}
+		})
+	}
+}
+
+// recordingStore Store that keeps the last record saved of each job, taking a random amount of time to save so that
+// concurrent saves interleave.
+type recordingStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func (s *recordingStore) Save(record Record) error {
+	time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ID] = record
+
+	return nil
+}
+
+func (s *recordingStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, id)
+
+	return nil
+}
+
+func (s *recordingStore) Load() ([]Record, error) {
+	return nil, nil
+}
+
+func TestJob_save(t *testing.T) {
+	store := &recordingStore{records: make(map[string]Record)}
+	j := &Job{id: "job", store: store, stdoutIndex: newOutputIndex(), stderrIndex: newOutputIndex()}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			j.mu.Lock()
+			j.statusInfo = fmt.Sprint(i)
+			j.mu.Unlock()
+
+			j.save()
+		}()
+	}
+
+	wg.Wait()
+
+	if got, want := store.records[j.id].StatusInfo, j.Record().StatusInfo; got != want {
+		t.Errorf("saved record status info = %s, want the latest %s", got, want)
+	}
+}
+
+func TestJob_Discard(t *testing.T) {
+	root := t.TempDir()
+	cg, err := cgroups.NewCgroup(root, "worker", "job")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &recordingStore{records: make(map[string]Record)}
+	j := &Job{
+		id:          "job",
+		cgroup:      cg,
+		outputDir:   t.TempDir(),
+		store:       store,
+		stdoutIndex: newOutputIndex(),
+		stderrIndex: newOutputIndex(),
+	}
+
+	j.save()
+	j.Discard()
+
+	if _, ok := store.records[j.id]; ok {
+		t.Error("Discard() did not delete the job's record")
+	}
+
+	for _, dir := range []string{filepath.Join(root, "worker", "job"), j.outputDir} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Discard() did not remove %s: %v", dir, err)
+		}
+	}
+}
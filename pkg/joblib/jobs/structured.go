@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// TextFormat Output is treated as raw text; the default.
+	TextFormat = OutputFormat("text")
+	// JSONFormat Each line of output is parsed as a JSON object, falling back to raw text for lines that aren't one.
+	JSONFormat = OutputFormat("json")
+)
+
+var (
+	// ErrNotStructured Returned when filtering the output of a job whose output isn't parsed as structured logs.
+	ErrNotStructured = errors.New("job output is not structured; field filters require the json output format")
+)
+
+// OutputFormat Format of the lines a job's command writes to stdout and stderr.
+type OutputFormat string
+
+// Validate Ensure the output format is known; the empty format is the same as TextFormat.
+func (f OutputFormat) Validate() error {
+	switch f {
+	case "", TextFormat, JSONFormat:
+		return nil
+	}
+
+	return fmt.Errorf("unknown output format: %s", f)
+}
+
+// Line Line of a job's output parsed according to the job's output format.
+type Line struct {
+	// Raw Content of the line without its newline.
+	Raw []byte
+	// Fields Fields of the line's JSON object; nil if the line isn't a JSON object or the output isn't parsed as JSON.
+	Fields map[string]any
+}
+
+// ParseLine Parse a line of output in the format; a line that cannot be parsed is kept as raw text.
+func ParseLine(format OutputFormat, raw []byte) Line {
+	line := Line{Raw: raw}
+
+	if format != JSONFormat {
+		return line
+	}
+
+	trimmed := bytes.TrimSpace(raw)
+
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return line
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+
+	var fields map[string]any
+
+	if err := decoder.Decode(&fields); err != nil || decoder.More() {
+		return line
+	}
+
+	line.Fields = fields
+
+	return line
+}
+
+// Structured Returns true if the line was parsed as a JSON object.
+func (l Line) Structured() bool {
+	return l.Fields != nil
+}
+
+// Value Returns the decoded value of the field and true if the line has the field. Nested fields are separated by dots,
+// e.g. "http.status"; numbers are decoded as json.Number.
+func (l Line) Value(name string) (any, bool) {
+	var value any = l.Fields
+
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]any)
+
+		if !ok {
+			return nil, false
+		}
+
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// Field Returns the value of the field as text and true if the line has the field. Nested fields are separated by dots,
+// e.g. "http.status"; objects and arrays are returned as JSON.
+func (l Line) Field(name string) (string, bool) {
+	value, ok := l.Value(name)
+
+	if !ok {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case nil:
+		return "null", true
+	case bool, map[string]any, []any:
+		data, err := json.Marshal(v)
+
+		return string(data), err == nil
+	}
+
+	return fmt.Sprint(value), true
+}
+
+// FieldFilter Matches structured lines of output whose field has a value.
+type FieldFilter struct {
+	// Field Name of the field; nested fields are separated by dots.
+	Field string
+	// Value Text the field's value must equal.
+	Value string
+}
+
+// ParseFieldFilter Parse a filter in the form "field=value", e.g. "level=error".
+func ParseFieldFilter(filter string) (FieldFilter, error) {
+	field, value, ok := strings.Cut(filter, "=")
+
+	if !ok || field == "" {
+		return FieldFilter{}, fmt.Errorf("field filter must be in the form field=value: %s", filter)
+	}
+
+	return FieldFilter{Field: field, Value: value}, nil
+}
+
+// Matches Returns true if the line has the filter's field with the filter's value; unstructured lines never match.
+func (f FieldFilter) Matches(line Line) bool {
+	value, ok := line.Field(f.Field)
+
+	return ok && value == f.Value
+}
+
+// matchesFilters Returns true if the line matches every filter.
+func matchesFilters(filters []FieldFilter, line Line) bool {
+	for _, filter := range filters {
+		if !filter.Matches(line) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OutputFormat Returns the format of the lines the job's command writes.
+func (j *Job) OutputFormat() OutputFormat {
+	if j.outputFormat == "" {
+		return TextFormat
+	}
+
+	return j.outputFormat
+}
+
+// filterReader Reads only the lines of output that match every filter.
+type filterReader struct {
+	reader  *bufio.Reader
+	closer  io.Closer
+	format  OutputFormat
+	filters []FieldFilter
+	pending []byte
+	err     error
+}
+
+// newFilterReader Create a reader that reads only the lines from the reader matching every filter.
+func newFilterReader(r io.ReadCloser, format OutputFormat, filters []FieldFilter) *filterReader {
+	return &filterReader{
+		reader:  bufio.NewReader(r),
+		closer:  r,
+		format:  format,
+		filters: filters,
+	}
+}
+
+func (r *filterReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		// Lines longer than the maximum are truncated, so they can never be parsed and are always filtered out
+		data, size, err := readLine(r.reader)
+
+		if err != nil {
+			r.err = err
+		}
+
+		if size > 0 && size <= maxSearchLineBytes+1 && matchesFilters(r.filters, ParseLine(r.format, data)) {
+			r.pending = append(data, '\n')
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+func (r *filterReader) Close() error {
+	return r.closer.Close()
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"io"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    OutputFormat
+		raw       string
+		field     string
+		wantValue string
+		wantOk    bool
+	}{
+		{
+			name:      "Should parse field of JSON line",
+			format:    JSONFormat,
+			raw:       `{"level":"error","msg":"disk full"}`,
+			field:     "level",
+			wantValue: "error",
+			wantOk:    true,
+		},
+		{
+			name:      "Should parse nested field of JSON line",
+			format:    JSONFormat,
+			raw:       `{"http":{"status":500}}`,
+			field:     "http.status",
+			wantValue: "500",
+			wantOk:    true,
+		},
+		{
+			name:   "Should fall back to raw text for line that isn't JSON",
+			format: JSONFormat,
+			raw:    "level=error",
+			field:  "level",
+			wantOk: false,
+		},
+		{
+			name:   "Should not parse JSON line of text output",
+			format: TextFormat,
+			raw:    `{"level":"error"}`,
+			field:  "level",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := ParseLine(tt.format, []byte(tt.raw))
+
+			if string(line.Raw) != tt.raw {
+				t.Errorf("ParseLine() raw = %s, want %s", line.Raw, tt.raw)
+			}
+
+			value, ok := line.Field(tt.field)
+
+			if ok != tt.wantOk || value != tt.wantValue {
+				t.Errorf("Field() = %s, %v, want %s, %v", value, ok, tt.wantValue, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestJob_OutputFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  OutputFormat
+		filters []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Should read only lines matching every filter",
+			format:  JSONFormat,
+			filters: []string{"level=error", "code=1"},
+			want:    "{\"level\":\"error\",\"code\":1}\n",
+		},
+		{
+			name:    "Should reject filters for text output",
+			format:  TextFormat,
+			filters: []string{"level=error"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{time: UnixEpoch()}
+
+			j := &Job{
+				clock:        clock,
+				outputDir:    t.TempDir(),
+				outputFormat: tt.format,
+				stdoutIndex:  newOutputIndex(),
+				stderrIndex:  newOutputIndex(),
+			}
+
+			output := "starting\n{\"level\":\"info\",\"code\":1}\n{\"level\":\"error\",\"code\":1}\n{\"level\":\"error\",\"code\":2}"
+			writeOutput(t, j, RotationPolicy{}, output, "")
+
+			var filters []FieldFilter
+
+			for _, filter := range tt.filters {
+				f, err := ParseFieldFilter(filter)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				filters = append(filters, f)
+			}
+
+			stdout, stderr, err := j.Output(OutputRange{}, filters...)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Output() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			defer stdout.Close()
+			defer stderr.Close()
+
+			got, err := io.ReadAll(stdout)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("Output() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// PendingStep Status of a step waiting for the steps it depends on to succeed.
+	PendingStep = StepStatus("pending")
+	// RunningStep Status of a step whose job has been started or queued and hasn't ended.
+	RunningStep = StepStatus("running")
+	// SucceededStep Status of a step whose job succeeded.
+	SucceededStep = StepStatus("succeeded")
+	// FailedStep Status of a step whose job could not be started or ended without succeeding.
+	FailedStep = StepStatus("failed")
+	// SkippedStep Status of a step that was never started because a step it depends on didn't succeed.
+	SkippedStep = StepStatus("skipped")
+	// CanceledStep Status of a step that was never started or whose job was stopped because the workflow was canceled.
+	CanceledStep = StepStatus("canceled")
+
+	// RunningWorkflow Status of a workflow with steps that are pending or running.
+	RunningWorkflow = WorkflowStatus("running")
+	// SucceededWorkflow Status of a workflow whose steps all succeeded.
+	SucceededWorkflow = WorkflowStatus("succeeded")
+	// FailedWorkflow Status of a workflow that ended with a step that failed or was skipped.
+	FailedWorkflow = WorkflowStatus("failed")
+	// CanceledWorkflow Status of a workflow that was canceled and whose running steps have ended.
+	CanceledWorkflow = WorkflowStatus("canceled")
+
+	// CanceledInfo Status info of a step that was never started because its workflow was canceled.
+	CanceledInfo = "workflow was canceled"
+)
+
+// StepStatus Status of a step of a workflow.
+type StepStatus string
+
+// WorkflowStatus Combined status of the steps of a workflow.
+type WorkflowStatus string
+
+// Step Job of a workflow that is started once every step it depends on has succeeded.
+type Step struct {
+	// Name Name of the step; unique within its workflow.
+	Name string
+	// DependsOn Names of the steps that must succeed before the step's job is started.
+	DependsOn []string
+}
+
+// StepInfo Status of a step of a workflow.
+type StepInfo struct {
+	Step
+	Status StepStatus
+	// JobID ID of the step's job; empty if the job hasn't been started.
+	JobID string
+	// Info Human-readable information about the step's status, e.g. why it was skipped; may be empty.
+	Info string
+}
+
+// StartStep Creates the job of the named step and starts it, or submits it to a scheduler.
+type StartStep func(name string) (*Job, error)
+
+// Workflow Set of steps that are run as a directed acyclic graph: every step whose dependencies have succeeded is
+// started at the same time, and the steps that depend on a step that didn't succeed are skipped. Workflows are only
+// kept in memory, while the jobs of their steps are persisted like any other job.
+type Workflow struct {
+	mu       sync.Mutex
+	id       string
+	name     string
+	owner    string
+	created  time.Time
+	ended    time.Time
+	clock    clock.Clock
+	start    StartStep
+	steps    []*StepInfo
+	jobs     map[string]*Job
+	canceled bool
+}
+
+// ValidateSteps Ensure the steps can be run as a workflow, i.e. that their names are unique and their dependencies
+// exist and don't form a cycle.
+func ValidateSteps(steps []Step) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: a workflow requires at least one step", ErrInvalidWorkflow)
+	}
+
+	// Number of dependencies of each step that haven't been visited yet
+	remaining := make(map[string]int, len(steps))
+
+	for _, step := range steps {
+		if strings.TrimSpace(step.Name) == "" {
+			return fmt.Errorf("%w: every step requires a name", ErrInvalidWorkflow)
+		}
+
+		if _, ok := remaining[step.Name]; ok {
+			return fmt.Errorf("%w: step name is not unique: %s", ErrInvalidWorkflow, step.Name)
+		}
+
+		remaining[step.Name] = len(step.DependsOn)
+	}
+
+	for _, step := range steps {
+		for _, dependency := range step.DependsOn {
+			if _, ok := remaining[dependency]; !ok {
+				return fmt.Errorf("%w: step %s depends on unknown step %s", ErrInvalidWorkflow, step.Name, dependency)
+			}
+		}
+	}
+
+	// Steps are visited once all of their dependencies have been, so steps in a cycle are never visited
+	var visit []string
+
+	for _, step := range steps {
+		if remaining[step.Name] == 0 {
+			visit = append(visit, step.Name)
+		}
+	}
+
+	for len(visit) > 0 {
+		name := visit[0]
+		visit = visit[1:]
+
+		delete(remaining, name)
+
+		for _, step := range steps {
+			for _, dependency := range step.DependsOn {
+				if dependency != name {
+					continue
+				}
+
+				if remaining[step.Name]--; remaining[step.Name] == 0 {
+					visit = append(visit, step.Name)
+				}
+			}
+		}
+	}
+
+	if len(remaining) > 0 {
+		var cycle []string
+
+		for name := range remaining {
+			cycle = append(cycle, name)
+		}
+
+		slices.Sort(cycle)
+
+		return fmt.Errorf("%w: dependencies form a cycle: %s", ErrInvalidWorkflow, strings.Join(cycle, ", "))
+	}
+
+	return nil
+}
+
+// NewWorkflow Create a workflow from the steps whose jobs are started with the function; none are started until the
+// workflow is run.
+func NewWorkflow(name string, owner string, clock clock.Clock, steps []Step, start StartStep) (*Workflow, error) {
+	if err := ValidateSteps(steps); err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{
+		id:      uuid.NewString(),
+		name:    name,
+		owner:   owner,
+		created: clock.Now(),
+		clock:   clock,
+		start:   start,
+		steps:   make([]*StepInfo, 0, len(steps)),
+		jobs:    make(map[string]*Job, len(steps)),
+	}
+
+	for _, step := range steps {
+		step.DependsOn = slices.Clone(step.DependsOn)
+		w.steps = append(w.steps, &StepInfo{Step: step, Status: PendingStep})
+	}
+
+	return w, nil
+}
+
+// ID Returns the workflow's ID.
+func (w *Workflow) ID() string {
+	return w.id
+}
+
+// Name Returns the workflow's name; may be empty.
+func (w *Workflow) Name() string {
+	return w.name
+}
+
+// Owner Returns the identity of the client that submitted the workflow; empty if it is unknown.
+func (w *Workflow) Owner() string {
+	return w.owner
+}
+
+// Created Returns when the workflow was created.
+func (w *Workflow) Created() time.Time {
+	return w.created
+}
+
+// Ended Returns when the workflow ended and true if it has ended.
+func (w *Workflow) Ended() (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.ended, !w.ended.IsZero()
+}
+
+// Status Returns the combined status of the workflow's steps.
+func (w *Workflow) Status() WorkflowStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status()
+}
+
+// Steps Returns the status of each step in the order they were given.
+func (w *Workflow) Steps() []StepInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	steps := make([]StepInfo, 0, len(w.steps))
+
+	for _, step := range w.steps {
+		info := *step
+		info.DependsOn = slices.Clone(step.DependsOn)
+		steps = append(steps, info)
+	}
+
+	return steps
+}
+
+// Run Start every step without dependencies; the rest are started as the steps they depend on succeed.
+func (w *Workflow) Run() {
+	logger.Info("Running workflow", "id", w.id, "name", w.name)
+
+	w.advance()
+}
+
+// Cancel Stop the jobs of the workflow's running steps and never start its pending steps. Canceling a workflow that
+// was already canceled has no effect, while canceling a workflow that ended otherwise returns an error.
+func (w *Workflow) Cancel() error {
+	logger.Info("Canceling workflow", "id", w.id)
+
+	w.mu.Lock()
+
+	if w.canceled {
+		w.mu.Unlock()
+
+		return nil
+	}
+
+	if !w.ended.IsZero() {
+		status := w.status()
+		w.mu.Unlock()
+
+		return fmt.Errorf("%w: cannot cancel workflow with status %s", ErrWorkflowEnded, status)
+	}
+
+	w.canceled = true
+
+	var running []*Job
+
+	for _, step := range w.steps {
+		switch step.Status {
+		case PendingStep:
+			step.Status = CanceledStep
+			step.Info = CanceledInfo
+		case RunningStep:
+			// A step that is being started has no job yet; its job is stopped once it has been started
+			if job, ok := w.jobs[step.Name]; ok {
+				running = append(running, job)
+			}
+		}
+	}
+
+	w.endIfDone()
+	w.mu.Unlock()
+
+	for _, job := range running {
+		stopStepJob(job)
+	}
+
+	return nil
+}
+
+// advance Start every pending step whose dependencies have all succeeded.
+func (w *Workflow) advance() {
+	w.mu.Lock()
+
+	var ready []*StepInfo
+
+	for _, step := range w.steps {
+		if step.Status == PendingStep && w.dependenciesSucceeded(step) {
+			// The step is marked as running before the workflow is unlocked so that it is only started once
+			step.Status = RunningStep
+			ready = append(ready, step)
+		}
+	}
+
+	w.mu.Unlock()
+
+	for _, step := range ready {
+		w.startStep(step)
+	}
+}
+
+// startStep Start the job of the step, which must already be marked as running.
+func (w *Workflow) startStep(step *StepInfo) {
+	logger.Info("Starting workflow step", "id", w.id, "step", step.Name)
+
+	job, err := w.start(step.Name)
+
+	w.mu.Lock()
+
+	if err != nil {
+		logger.Warn("Failed to start workflow step", "id", w.id, "step", step.Name, "err", err)
+
+		step.Status = FailedStep
+		step.Info = err.Error()
+		w.skipDependents(step.Name)
+		w.endIfDone()
+		w.mu.Unlock()
+
+		return
+	}
+
+	step.JobID = job.ID()
+	w.jobs[step.Name] = job
+	canceled := w.canceled
+	w.mu.Unlock()
+
+	if canceled {
+		stopStepJob(job)
+	}
+
+	job.onEnd(func() {
+		w.stepEnded(step, job)
+	})
+}
+
+// stepEnded Record the status of the step whose job ended, skipping the steps that depend on it if it didn't succeed
+// and starting the steps that are now ready.
+func (w *Workflow) stepEnded(step *StepInfo, job *Job) {
+	w.mu.Lock()
+
+	switch status := job.Status(); {
+	case status == SucceededStatus:
+		step.Status = SucceededStep
+	case status == StoppedStatus && w.canceled:
+		step.Status = CanceledStep
+	default:
+		step.Status = FailedStep
+		step.Info = fmt.Sprintf("job %s", status)
+
+		if info := job.StatusInfo(); info != "" {
+			step.Info = fmt.Sprintf("%s: %s", step.Info, info)
+		}
+
+		w.skipDependents(step.Name)
+	}
+
+	logger.Info("Workflow step ended", "id", w.id, "step", step.Name, "status", step.Status)
+
+	w.endIfDone()
+	w.mu.Unlock()
+
+	w.advance()
+}
+
+// dependenciesSucceeded Returns true if every step the step depends on has succeeded.
+func (w *Workflow) dependenciesSucceeded(step *StepInfo) bool {
+	for _, dependency := range step.DependsOn {
+		i := slices.IndexFunc(w.steps, func(s *StepInfo) bool { return s.Name == dependency })
+
+		if w.steps[i].Status != SucceededStep {
+			return false
+		}
+	}
+
+	return true
+}
+
+// skipDependents Skip every pending step that depends on the named step, directly or through other steps.
+func (w *Workflow) skipDependents(name string) {
+	for _, step := range w.steps {
+		if step.Status != PendingStep || !slices.Contains(step.DependsOn, name) {
+			continue
+		}
+
+		step.Status = SkippedStep
+		step.Info = fmt.Sprintf("step %s did not succeed", name)
+
+		w.skipDependents(step.Name)
+	}
+}
+
+// status Returns the combined status of the workflow's steps; the workflow must be locked.
+func (w *Workflow) status() WorkflowStatus {
+	failed := false
+
+	for _, step := range w.steps {
+		switch step.Status {
+		case PendingStep, RunningStep:
+			return RunningWorkflow
+		case FailedStep, SkippedStep:
+			failed = true
+		}
+	}
+
+	if w.canceled {
+		return CanceledWorkflow
+	}
+
+	if failed {
+		return FailedWorkflow
+	}
+
+	return SucceededWorkflow
+}
+
+// endIfDone Record when the workflow ended if none of its steps are pending or running; the workflow must be locked.
+func (w *Workflow) endIfDone() {
+	if !w.ended.IsZero() {
+		return
+	}
+
+	if status := w.status(); status != RunningWorkflow {
+		w.ended = w.clock.Now()
+
+		logger.Info("Workflow ended", "id", w.id, "status", status)
+	}
+}
+
+// stopStepJob Stop the job of a step of a canceled workflow; the job may have already ended.
+func stopStepJob(job *Job) {
+	if err := job.Stop(); err != nil && !errors.Is(err, ErrJobEnded) {
+		logger.Warn("Failed to stop job of canceled workflow", "id", job.ID(), "err", err)
+	}
+}
@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/kurczynski/teleport-job-worker/internal/clock"
+	"github.com/kurczynski/teleport-job-worker/pkg/joblib/cgroups"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr bool
+	}{
+		{
+			name: "Should allow steps that form a DAG",
+			steps: []Step{
+				{Name: "build"},
+				{Name: "test", DependsOn: []string{"build"}},
+				{Name: "lint", DependsOn: []string{"build"}},
+				{Name: "package", DependsOn: []string{"test", "lint"}},
+			},
+		},
+		{
+			name:    "Should not allow a workflow without steps",
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow duplicate step names",
+			steps:   []Step{{Name: "build"}, {Name: "build"}},
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow unknown dependencies",
+			steps:   []Step{{Name: "test", DependsOn: []string{"build"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Should not allow a step to depend on itself",
+			steps:   []Step{{Name: "build", DependsOn: []string{"build"}}},
+			wantErr: true,
+		},
+		{
+			name: "Should not allow cycles",
+			steps: []Step{
+				{Name: "build"},
+				{Name: "test", DependsOn: []string{"build", "package"}},
+				{Name: "package", DependsOn: []string{"test"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSteps(tt.steps)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidWorkflow) {
+				t.Errorf("ValidateSteps() error = %v, want %v", err, ErrInvalidWorkflow)
+			}
+		})
+	}
+}
+
+func TestWorkflow_Run(t *testing.T) {
+	steps := []Step{
+		{Name: "build"},
+		{Name: "test", DependsOn: []string{"build"}},
+		{Name: "lint", DependsOn: []string{"build"}},
+		{Name: "package", DependsOn: []string{"test", "lint"}},
+	}
+
+	tests := []struct {
+		name       string
+		end        map[string]Status
+		cancel     bool
+		wantSteps  map[string]StepStatus
+		wantStatus WorkflowStatus
+	}{
+		{
+			name: "Should run steps as their dependencies succeed",
+			end: map[string]Status{
+				"build": SucceededStatus, "test": SucceededStatus, "lint": SucceededStatus, "package": SucceededStatus,
+			},
+			wantSteps: map[string]StepStatus{
+				"build": SucceededStep, "test": SucceededStep, "lint": SucceededStep, "package": SucceededStep,
+			},
+			wantStatus: SucceededWorkflow,
+		},
+		{
+			name: "Should skip the dependents of a failed step",
+			end:  map[string]Status{"build": SucceededStatus, "test": FailedStatus, "lint": SucceededStatus},
+			wantSteps: map[string]StepStatus{
+				"build": SucceededStep, "test": FailedStep, "lint": SucceededStep, "package": SkippedStep,
+			},
+			wantStatus: FailedWorkflow,
+		},
+		{
+			name:   "Should stop running steps and never start pending steps when canceled",
+			end:    map[string]Status{"build": SucceededStatus},
+			cancel: true,
+			wantSteps: map[string]StepStatus{
+				"build": SucceededStep, "test": CanceledStep, "lint": CanceledStep, "package": CanceledStep,
+			},
+			wantStatus: CanceledWorkflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{time: UnixEpoch()}
+			root := t.TempDir()
+			started := make(map[string]*Job)
+
+			start := func(name string) (*Job, error) {
+				started[name] = newRunningJob(t, root, name, clock)
+
+				return started[name], nil
+			}
+
+			w, err := NewWorkflow("some-workflow", "", clock, steps, start)
+
+			if err != nil {
+				t.Fatalf("NewWorkflow() error = %v", err)
+			}
+
+			w.Run()
+
+			// Jobs are ended in the order their steps are started, which starts the steps that depend on them
+			for _, name := range []string{"build", "test", "lint", "package"} {
+				if status, ok := tt.end[name]; ok && started[name] != nil {
+					started[name].updateStatus(status)
+				}
+			}
+
+			if tt.cancel {
+				if err := w.Cancel(); err != nil {
+					t.Fatalf("Cancel() error = %v", err)
+				}
+			}
+
+			got := make(map[string]StepStatus)
+
+			for _, step := range w.Steps() {
+				got[step.Name] = step.Status
+			}
+
+			if !reflect.DeepEqual(got, tt.wantSteps) {
+				t.Errorf("Steps() = %v, want %v", got, tt.wantSteps)
+			}
+
+			if status := w.Status(); status != tt.wantStatus {
+				t.Errorf("Status() = %s, want %s", status, tt.wantStatus)
+			}
+
+			if _, ended := w.Ended(); !ended {
+				t.Errorf("Ended() = false, want true")
+			}
+		})
+	}
+}
+
+// newRunningJob Returns a running job without a process so that stopping it only removes its cgroup.
+func newRunningJob(t *testing.T, root string, id string, clock clock.Clock) *Job {
+	if err := os.MkdirAll(filepath.Join(root, "some-worker", id), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cg, err := cgroups.OpenCgroup(root, "some-worker", id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Job{
+		id: id, clock: clock, status: RunningStatus, cgroup: cg, command: &exec.Cmd{}, halt: make(chan struct{}),
+	}
+}